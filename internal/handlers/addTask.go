@@ -14,29 +14,29 @@ func (h *Handler) AddTask(w http.ResponseWriter, r *http.Request) {
 	var request models.Task
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, errBadBody, http.StatusBadRequest)
 		return
 	}
 
 	err = request.CheckTask()
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, err, http.StatusBadRequest)
 		return
 	}
-	err = h.db.DateToAdd(&request)
+	err = request.DateToAdd(h.clock.Now())
 	if err != nil {
-		h.SendErr(w, err, http.StatusInternalServerError)
+		h.SendErr(w, r, err, errStatus(err))
 		return
 	}
-	lastInsertID, err := h.db.Insert(&request)
+	lastInsertID, err := h.db.Insert(userID(r), &request)
 	if err != nil {
-		h.SendErr(w, err, http.StatusInternalServerError)
+		h.SendErr(w, r, err, http.StatusInternalServerError)
 		return
 	}
 	id.ID = lastInsertID
 	response, err := json.Marshal(id)
 	if err != nil {
-		h.SendErr(w, err, http.StatusInternalServerError)
+		h.SendErr(w, r, err, http.StatusInternalServerError)
 		return
 	}
 	h.logger.Infof("sent response via handler Task (method %s)", r.Method)
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go_final_project/internal/models"
+	"go_final_project/internal/utils"
+)
+
+// Agenda обрабатывает GET-запрос /api/agenda?days=<N> и возвращает активные задачи пользователя, разложенные по срокам:
+// просроченные, на сегодня, на завтра, на эту неделю, позже и без даты. Повторяющиеся задачи разворачиваются
+// в повторения с датами в окне из days дней, начиная с сегодняшнего (по умолчанию models.DefaultAgendaDays,
+// не больше models.MaxAgendaDays).
+//
+// Параметры:
+// - w: http.ResponseWriter для записи ответа.
+// - r: http.Request, содержащий данные запроса.
+//
+// Возвращает:
+// - Записывает JSON-ответ с повесткой в ответный writer.
+// - Если days неверен, отправляет ответ с ошибкой с кодом состояния 400.
+func (h *Handler) Agenda(w http.ResponseWriter, r *http.Request) {
+	days := models.DefaultAgendaDays
+	if value := r.FormValue("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 1 || days > models.MaxAgendaDays {
+			h.SendErr(w, r, utils.NewError(utils.CodeInvalidRequest, "days", "days должен быть числом от 1 до %d",
+				models.MaxAgendaDays), http.StatusBadRequest)
+			return
+		}
+	}
+	agenda := models.NewAgenda(h.clock.Now(), days)
+	tasks, err := h.db.Between(userID(r), "", agenda.To)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	agenda.Fill(tasks)
+	h.sendJSON(w, r, agenda)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go_final_project/internal/models"
+	"go_final_project/internal/utils"
+)
+
+// GetAPIKeys возвращает список API-ключей текущего пользователя в поле "keys".
+// Значения ключей не возвращаются, только их префиксы.
+func (h *Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if viaAPIKey(r) {
+		h.SendErr(w, r, utils.Errorf("API-ключом нельзя управлять API-ключами"), http.StatusForbidden)
+		return
+	}
+	keys, err := h.db.ListAPIKeys(userID(r))
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	h.sendJSON(w, r, map[string][]models.APIKey{"keys": keys})
+}
+
+// AddAPIKey создаёт API-ключ с полями "name" и "scope" ("read" или "read-write", по умолчанию "read").
+// Значение ключа возвращается в поле "key" только в этом ответе.
+func (h *Handler) AddAPIKey(w http.ResponseWriter, r *http.Request) {
+	if viaAPIKey(r) {
+		h.SendErr(w, r, utils.Errorf("API-ключом нельзя управлять API-ключами"), http.StatusForbidden)
+		return
+	}
+	var request struct {
+		Name  string `json:"name"`
+		Scope string `json:"scope"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		h.SendErr(w, r, errBadBody, http.StatusBadRequest)
+		return
+	}
+	if request.Scope == "" {
+		request.Scope = models.ScopeRead
+	}
+	if request.Scope != models.ScopeRead && request.Scope != models.ScopeReadWrite {
+		h.SendErr(w, r, utils.NewError(utils.CodeInvalidRequest, "scope", "область действия должна быть read или read-write"), http.StatusBadRequest)
+		return
+	}
+	key, secret, err := h.db.CreateAPIKey(userID(r), request.Name, request.Scope)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	h.sendJSON(w, r, struct {
+		*models.APIKey
+		Key string `json:"key"`
+	}{key, secret})
+}
+
+// DeleteAPIKey отзывает API-ключ с идентификатором из параметра "id".
+// Если вместо него передан параметр "unused_days", удаляются все ключи, не использовавшиеся указанное число дней,
+// а их количество возвращается в поле "deleted".
+func (h *Handler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	if viaAPIKey(r) {
+		h.SendErr(w, r, utils.Errorf("API-ключом нельзя управлять API-ключами"), http.StatusForbidden)
+		return
+	}
+	if days := r.FormValue("unused_days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			h.SendErr(w, r, utils.NewError(utils.CodeInvalidRequest, "unused_days", "не удаётся разобрать unused_days"), http.StatusBadRequest)
+			return
+		}
+		deleted, err := h.db.PruneAPIKeys(userID(r), h.clock.Now().AddDate(0, 0, -n))
+		if err != nil {
+			h.SendErr(w, r, err, http.StatusInternalServerError)
+			return
+		}
+		h.sendJSON(w, r, map[string]int64{"deleted": deleted})
+		return
+	}
+	id, err := h.GetID(r)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	err = h.db.DeleteAPIKey(userID(r), int64(id))
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	h.sendJSON(w, r, struct{}{})
+}
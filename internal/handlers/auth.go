@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"go_final_project/internal/models"
+	"go_final_project/internal/utils"
+)
+
+// Типы токенов, которые записываются в claim "token_type".
+const (
+	accessToken  = "access"
+	refreshToken = "refresh"
+)
+
+// ctxKey - тип ключей, под которыми промежуточные обработчики сохраняют данные в контексте запроса.
+type ctxKey int
+
+const (
+	userKey ctxKey = iota
+	apiKeyKey
+)
+
+// Auth - промежуточный обработчик, который проверяет JWT-токен перед вызовом next.
+// Токен берётся из cookie "token" или из заголовка "Authorization: Bearer <token>".
+// Проверяется подпись и срок действия токена, соответствие claim "hashedPass" хэшу текущего пароля
+// и отсутствие токена в списке отозванных.
+// Идентификатор пользователя из claim "sub" сохраняется в контексте запроса, его возвращает userID.
+// Вместо токена можно передать API-ключ в заголовке "X-API-Key"; ключ с областью "read" допускает только запросы GET.
+// Если переменная окружения TODO_PASSWORD пуста, аутентификация отключена и запрос выполняется от имени пользователя по умолчанию.
+//
+// Параметры:
+// - next: обработчик, который вызывается после успешной проверки токена.
+//
+// Возвращает:
+// - Обработчик, отвечающий ошибкой 401 в формате JSON, если токен отсутствует или недействителен.
+func (h *Handler) Auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		password := utils.CheckPassword()
+		if password == "" {
+			next(w, withUser(r, models.User{ID: models.DefaultUserID}))
+			return
+		}
+		if secret := r.Header.Get("X-API-Key"); secret != "" {
+			h.authAPIKey(w, r, secret, next)
+			return
+		}
+		token := tokenFromRequest(r)
+		if token == "" {
+			h.SendErr(w, r, utils.Errorf("требуется аутентификация"), http.StatusUnauthorized)
+			return
+		}
+		claims, err := h.validateToken(token, password, accessToken)
+		if err != nil {
+			h.SendErr(w, r, err, http.StatusUnauthorized)
+			return
+		}
+		next(w, withUser(r, userFromClaims(claims)))
+	}
+}
+
+// authAPIKey проверяет API-ключ secret и его область действия и вызывает next от имени владельца ключа.
+func (h *Handler) authAPIKey(w http.ResponseWriter, r *http.Request, secret string, next http.HandlerFunc) {
+	key, err := h.db.UseAPIKey(secret)
+	if errors.Is(err, models.ErrInvalidAPIKey) {
+		h.SendErr(w, r, err, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	if key.Scope == models.ScopeRead && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.SendErr(w, r, utils.Errorf("API-ключ только для чтения"), http.StatusForbidden)
+		return
+	}
+	r = withUser(r, models.User{ID: key.UserID})
+	next(w, r.WithContext(context.WithValue(r.Context(), apiKeyKey, key)))
+}
+
+// viaAPIKey сообщает, был ли запрос аутентифицирован API-ключом.
+func viaAPIKey(r *http.Request) bool {
+	_, ok := r.Context().Value(apiKeyKey).(*models.APIKey)
+	return ok
+}
+
+// withUser возвращает копию запроса, в контексте которой сохранён пользователь.
+func withUser(r *http.Request, user models.User) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userKey, user))
+}
+
+// userID возвращает идентификатор пользователя, от имени которого выполняется запрос.
+// Для запросов, не прошедших через Auth, возвращается пользователь по умолчанию.
+func userID(r *http.Request) int64 {
+	user, ok := r.Context().Value(userKey).(models.User)
+	if !ok {
+		return models.DefaultUserID
+	}
+	return user.ID
+}
+
+// userFromClaims извлекает пользователя из claims "sub" и "login".
+// Токены, выданные до появления учётных записей, принадлежат пользователю по умолчанию.
+func userFromClaims(claims jwt.MapClaims) models.User {
+	user := models.User{ID: models.DefaultUserID}
+	sub, _ := claims.GetSubject()
+	if id, err := strconv.ParseInt(sub, 10, 64); err == nil {
+		user.ID = id
+	}
+	user.Login, _ = claims["login"].(string)
+	return user
+}
+
+// tokenFromRequest извлекает токен из заголовка Authorization или из cookie "token".
+// Если токен не передан, возвращается пустая строка.
+func tokenFromRequest(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	cookie, err := r.Cookie("token")
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// validateToken проверяет подпись и срок действия токена, его тип, claim "hashedPass"
+// и наличие идентификатора токена в списке отозванных.
+//
+// Параметры:
+// - token: подписанный JWT-токен.
+// - password: текущий пароль, которым подписываются токены.
+// - tokenType: ожидаемый тип токена (accessToken или refreshToken).
+//
+// Возвращает:
+// - Claims токена и ошибку, если токен недействителен, отозван или был выдан для другого пароля.
+func (h *Handler) validateToken(token, password, tokenType string) (jwt.MapClaims, error) {
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (any, error) {
+		return []byte(password), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(h.clock.Now),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, utils.Errorf("срок действия токена истёк")
+	}
+	if err != nil || !parsed.Valid {
+		return nil, utils.Errorf("неверный токен")
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, utils.Errorf("неверные данные токена")
+	}
+	if claims["token_type"] != tokenType {
+		return nil, utils.Errorf("ожидается токен %s", tokenType)
+	}
+	hashedPass, _ := claims["hashedPass"].(string)
+	if subtle.ConstantTimeCompare([]byte(hashedPass), []byte(hashPassword(password))) != 1 {
+		return nil, utils.Errorf("токен выдан для другого пароля")
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, utils.Errorf("у токена нет идентификатора")
+	}
+	revoked, err := h.db.IsRevoked(jti)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, utils.Errorf("токен отозван")
+	}
+	return claims, nil
+}
+
+// issueTokens выпускает пару токенов: токен доступа и токен обновления.
+// Время жизни токенов задаётся переменными окружения TODO_TOKEN_TTL и TODO_REFRESH_TTL.
+//
+// Параметры:
+// - password: текущий пароль, которым подписываются токены.
+// - user: пользователь, которому выдаются токены.
+//
+// Возвращает:
+// - Подписанные токен доступа и токен обновления и ошибку, если подписать токены не удалось.
+func (h *Handler) issueTokens(password string, user models.User) (string, string, error) {
+	now := h.clock.Now()
+	access, err := signToken(password, user, accessToken, now, utils.CheckTokenTTL())
+	if err != nil {
+		return "", "", err
+	}
+	refresh, err := signToken(password, user, refreshToken, now, utils.CheckRefreshTTL())
+	if err != nil {
+		return "", "", err
+	}
+	return access, refresh, nil
+}
+
+// signToken подписывает токен пользователя user указанного типа со случайным идентификатором,
+// выпущенный в момент now со временем жизни ttl.
+func signToken(password string, user models.User, tokenType string, now time.Time, ttl time.Duration) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	claims := jwt.MapClaims{
+		"hashedPass": hashPassword(password),
+		"token_type": tokenType,
+		"sub":        strconv.FormatInt(user.ID, 10),
+		"login":      user.Login,
+		"jti":        hex.EncodeToString(jti),
+		"iat":        now.Unix(),
+		"exp":        now.Add(ttl).Unix(),
+	}
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signedToken, err := jwtToken.SignedString([]byte(password))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign jwt: %s", err)
+	}
+	return signedToken, nil
+}
+
+// revokeClaims добавляет токен с указанными claims в список отозванных до окончания срока его действия.
+func (h *Handler) revokeClaims(claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return utils.Errorf("у токена нет срока действия")
+	}
+	return h.db.RevokeToken(jti, exp.Time)
+}
+
+// hashPassword возвращает SHA-256 хэш пароля в шестнадцатеричном виде.
+func hashPassword(password string) string {
+	hashString := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(hashString[:])
+}
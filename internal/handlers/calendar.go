@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go_final_project/internal/models"
+)
+
+// Calendar обрабатывает GET-запрос /api/calendar?from=<дата>&to=<дата> и возвращает все повторения активных задач
+// пользователя с датами от from до to включительно, например для сетки месяца. Повторения повторяющихся задач
+// вычисляются по их правилам и отмечаются как virtual, повторение с датой, сохранённой в задаче, - как хранимое.
+// Диапазон не длиннее models.MaxCalendarDays дней, в ответ попадает не больше models.MaxExpanded повторений.
+//
+// Параметры:
+// - w: http.ResponseWriter для записи ответа.
+// - r: http.Request, содержащий данные запроса.
+//
+// Возвращает:
+// - Записывает JSON-ответ с повторениями в ответный writer.
+// - Если диапазон неверен, отправляет ответ с ошибкой с кодом состояния 400.
+func (h *Handler) Calendar(w http.ResponseWriter, r *http.Request) {
+	calendar, err := models.NewCalendar(r.FormValue("from"), r.FormValue("to"))
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	tasks, err := h.db.Between(userID(r), calendar.From, calendar.To)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	calendar.Fill(tasks)
+	h.sendJSON(w, r, calendar)
+}
@@ -5,17 +5,17 @@ import "net/http"
 func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	id, err := h.GetID(r)
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, err, http.StatusBadRequest)
 		return
 	}
-	err = h.db.CheckID(id)
+	err = h.db.CheckID(userID(r), id)
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, err, errStatus(err))
 		return
 	}
-	err = h.db.Delete(id)
+	err = h.db.Delete(userID(r), id)
 	if err != nil {
-		h.SendErr(w, err, http.StatusInternalServerError)
+		h.SendErr(w, r, err, errStatus(err))
 		return
 	}
 	h.logger.Infof("sent response via handler Task (method %s)", r.Method)
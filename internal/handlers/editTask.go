@@ -2,47 +2,51 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"go_final_project/internal/models"
+	"go_final_project/internal/utils"
 )
 
 func (h *Handler) EditTask(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.SendErr(w, r, errBadBody, http.StatusBadRequest)
+		return
+	}
 	var task models.Task
-	err := json.NewDecoder(r.Body).Decode(&task)
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &task) != nil || json.Unmarshal(body, &fields) != nil {
+		h.SendErr(w, r, errBadBody, http.StatusBadRequest)
+		return
+	}
+	id, err := strconv.Atoi(task.ID)
 	if err != nil {
-		err = fmt.Errorf("can't parse response")
-		h.SendErr(w, err, http.StatusBadRequest)
+		err = utils.NewError(utils.CodeInvalidID, "id", "не удаётся разобрать id")
+		h.SendErr(w, r, err, http.StatusBadRequest)
 		return
 	}
+	stored, err := h.db.GetTask(userID(r), id)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	keepOmitted(&task, stored, fields)
 	err = task.CheckTask()
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, err, http.StatusBadRequest)
 		return
-	} else {
-		id, err := strconv.Atoi(task.ID)
-		if err != nil {
-			err = fmt.Errorf("cat not parse id")
-			h.SendErr(w, err, http.StatusBadRequest)
-			return
-		}
-
-		err = h.db.CheckID(id)
-		if err != nil {
-			h.SendErr(w, err, http.StatusBadRequest)
-			return
-		}
-		task.Date, err = task.CheckDate()
-		if err != nil {
-			h.SendErr(w, err, http.StatusBadRequest)
-			return
-		}
 	}
-	err = h.db.Update(&task)
+	task.Date, err = task.CheckDate(h.clock.Now())
 	if err != nil {
-		h.SendErr(w, err, http.StatusInternalServerError)
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	err = h.db.Update(userID(r), &task)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
 		return
 	}
 	h.logger.Infof("sent response via handler Task (method %s)", r.Method)
@@ -52,3 +56,25 @@ func (h *Handler) EditTask(w http.ResponseWriter, r *http.Request) {
 		h.logger.Error(err)
 	}
 }
+
+// keepOmitted переносит в изменённую задачу task из сохранённой задачи stored необязательные поля, которых нет
+// в теле запроса fields, чтобы клиенты, не знающие об этих полях, не сбрасывали их. Название, дата, правило повторения
+// и комментарий заменяются всегда. Окончание повторений сохраняется, только если у задачи осталось правило повторения.
+func keepOmitted(task, stored *models.Task, fields map[string]json.RawMessage) {
+	optional := map[string]func(){
+		"roll":     func() { task.Roll = stored.Roll },
+		"time":     func() { task.Time = stored.Time },
+		"timezone": func() { task.Timezone = stored.Timezone },
+		"priority": func() { task.Priority = stored.Priority },
+		"tags":     func() { task.Tags = stored.Tags },
+	}
+	if task.Repeat != "" {
+		optional["repeat_until"] = func() { task.RepeatUntil = stored.RepeatUntil }
+		optional["repeat_count"] = func() { task.RepeatCount = stored.RepeatCount }
+	}
+	for name, keep := range optional {
+		if _, ok := fields[name]; !ok {
+			keep()
+		}
+	}
+}
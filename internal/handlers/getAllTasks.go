@@ -7,7 +7,6 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
 
 	_ "modernc.org/sqlite"
@@ -20,6 +19,12 @@ import (
 // Задачи сортируются по дате в порядке возрастания.
 // Каждая задача содержит все поля таблицы scheduler в виде строк.
 // Дата представлена в формате 20060102.
+// По умолчанию возвращаются только невыполненные задачи; параметр status=done возвращает выполненные,
+// status=all - все задачи.
+// Параметры limit, sort (date, title, created, priority), order (asc, desc) и cursor задают размер страницы,
+// сортировку и позицию страницы. Если указан хотя бы один из них, ответ дополняется курсором следующей страницы
+// next_cursor и общим числом задач total; без них ответ содержит только поле tasks, как раньше.
+// Параметры страниц действуют и при поиске; поисковый запрос search разбирается функцией models.ParseFilter.
 //
 // Параметры:
 // - w: http.ResponseWriter для записи ответа.
@@ -28,34 +33,36 @@ import (
 // Возвращает:
 // // - Функция не возвращает никакого значения, но записывает JSON-ответ в http.ResponseWriter.
 func (h *Handler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
-	const limit = 50
-	var tasks map[string][]models.Task
-	search := r.FormValue("search")
-	var isSearch bool = search != ""
-	var err error
-	if isSearch {
-		tasks, err = h.db.Search(search, limit)
-		if err != nil {
-			h.SendErr(w, err, http.StatusInternalServerError)
-			return
-		}
+	query, err := models.ParseTaskQuery(r.FormValue("status"), r.FormValue("limit"), r.FormValue("sort"),
+		r.FormValue("order"), r.FormValue("cursor"))
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	var page models.TaskPage
+	if search := r.FormValue("search"); search != "" {
+		page, err = h.db.Search(userID(r), search, query)
 	} else {
-		var err error
-		tasks, err = h.db.GetAll(limit)
-		if err != nil {
-			h.SendErr(w, err, http.StatusInternalServerError)
-			return
-		}
+		page, err = h.db.GetAll(userID(r), query)
 	}
-	response, err := json.Marshal(tasks)
 	if err != nil {
-		h.SendErr(w, err, http.StatusInternalServerError)
+		h.SendErr(w, r, err, errStatus(err))
 		return
 	}
 	h.logger.Infof("sent response via handler GetTasks")
-	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	_, err = w.Write(response)
-	if err != nil {
-		h.logger.Error(err)
+	if paginated(r) {
+		h.sendJSON(w, r, page)
+		return
+	}
+	h.sendJSON(w, r, map[string][]models.Task{"tasks": page.Tasks})
+}
+
+// paginated проверяет, указаны ли в запросе параметры страниц, при которых ответ содержит next_cursor и total.
+func paginated(r *http.Request) bool {
+	for _, name := range []string{"limit", "sort", "order", "cursor"} {
+		if r.Form.Has(name) {
+			return true
+		}
 	}
+	return false
 }
@@ -8,18 +8,18 @@ import (
 func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 	id, err := h.GetID(r)
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, err, http.StatusBadRequest)
 		return
 	}
-	task, err := h.db.GetTask(id)
+	task, err := h.db.GetTask(userID(r), id)
 	if err != nil {
-		h.SendErr(w, err, http.StatusInternalServerError)
+		h.SendErr(w, r, err, errStatus(err))
 		return
 	}
 
 	response, err := json.Marshal(task)
 	if err != nil {
-		h.SendErr(w, err, http.StatusInternalServerError)
+		h.SendErr(w, r, err, http.StatusInternalServerError)
 		return
 	}
 	h.logger.Infof("sent response via handler Task (method %s)", r.Method)
@@ -1,50 +1,160 @@
 package handlers
 
 import (
-	"fmt"
+	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"go.uber.org/zap"
 
 	"go_final_project/internal/models"
+	"go_final_project/internal/utils"
 )
 
 type Handler struct {
-	db     *models.DBConnection
-	logger *zap.SugaredLogger
+	db      models.Store
+	clock   utils.Clock
+	logger  *zap.SugaredLogger
+	limiter *loginLimiter
 }
 
 // NewHandler создает новый экземпляр обработчика с указанным сервисом и журналом.
-// Инициализирует новую структуру Handler с указанным сервисом и журналом.
+// Инициализирует новую структуру Handler с указанным сервисом и журналом,
+// а также ограничитель попыток входа, который сохраняет счётчики в базе данных, если задан TODO_LOGIN_PERSIST.
 //
 // Параметры:
-// - dbConnection: Указатель на экземпляр dbConnection, предоставляющий методы для управления задачами.
+// - store: Хранилище задач и учётных записей.
+// - clock: Часы, по которым вычисляются даты задач и отметки времени.
 // - logger: Указатель на экземпляр SugaredLogger, который используется для ведения журнала.
 //
 // Возвращает:
 // - Новый экземпляр обработчика.
-func NewHandler(dbConnection *models.DBConnection, logger *zap.SugaredLogger) *Handler {
+func NewHandler(store models.Store, clock utils.Clock, logger *zap.SugaredLogger) *Handler {
 	return &Handler{
-		db:     dbConnection,
-		logger: logger,
+		db:      store,
+		clock:   clock,
+		logger:  logger,
+		limiter: newLoginLimiter(store, logger, utils.CheckLoginPersist()),
 	}
 }
 
+// errBadBody возвращается, если тело запроса не удаётся разобрать как JSON.
+var errBadBody = utils.NewError(utils.CodeInvalidRequest, "", "не удаётся разобрать тело запроса")
+
+// errorResponse - тело ответа с ошибкой. Поле error содержит сообщение, code - машиночитаемый код,
+// field - поле запроса, к которому относится ошибка, details - необязательные подробности.
+type errorResponse struct {
+	Error   string            `json:"error"`
+	Code    string            `json:"code"`
+	Field   string            `json:"field,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
 // SendErr - это метод для типа Handler, которая отправляет клиенту ответ об ошибке.
-// Она устанавливает соответствующий HTTP-код состояния и тип содержимого, а также форматирует сообщение об ошибке как JSON-объект.
+// Она устанавливает соответствующий HTTP-код состояния и тип содержимого, а также сериализует ошибку в JSON-объект.
+// Код, поле и подробности берутся из *utils.Error в цепочке err; для остальных ошибок код выводится из статуса.
+// Сообщение переводится на язык запроса (см. language); текст внутренних ошибок без перевода
+// заменяется общим сообщением и остаётся только в журнале.
 //
 // Параметры:
 // w - http.ResponseWriter, куда будет записан ответ.
+// r - запрос, по которому выбирается язык сообщения.
 // err - объект ошибки, содержащий сообщение об ошибке для отправки.
 // status - целое число, представляющее HTTP-код состояния для отправки.
 //
 // Возвращает:
 // Эта функция не возвращает никакого значения.
-func (h *Handler) SendErr(w http.ResponseWriter, err error, status int) {
+func (h *Handler) SendErr(w http.ResponseWriter, r *http.Request, err error, status int) {
 	h.logger.Error(err)
+	lang := language(r)
+	response := errorResponse{Error: utils.Localize(err, lang), Code: statusCode(status)}
+	if codeErr := utils.AsError(err); codeErr != nil {
+		response.Code, response.Field, response.Details = codeErr.Code, codeErr.Field, codeErr.Details
+	} else if _, ok := err.(*utils.Error); !ok && status >= http.StatusInternalServerError {
+		response.Error = utils.Translate(lang, utils.MsgInternal)
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		body = []byte(`{"error":"internal error","code":"internal"}`)
+	}
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	http.Error(w, fmt.Sprintf(`{"error": "%s"}`, err.Error()), status)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	if err != nil {
+		h.logger.Error(err)
+	}
+}
+
+// language возвращает язык ответа на запрос r: из параметра запроса "lang", затем из cookie "lang",
+// в которой веб-интерфейс хранит выбранный пользователем язык, и затем из заголовка Accept-Language.
+func language(r *http.Request) string {
+	if lang := r.URL.Query().Get("lang"); utils.IsLanguage(lang) {
+		return lang
+	}
+	if cookie, err := r.Cookie("lang"); err == nil && utils.IsLanguage(cookie.Value) {
+		return cookie.Value
+	}
+	return utils.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+// errStatus возвращает HTTP-код состояния для ошибки по её коду: 404 для отсутствующей задачи или записи,
+// 409 для конфликта, 401 и 403 для ошибок доступа, 400 для остальных ошибок проверки данных
+// и 500 для ошибок без кода.
+func errStatus(err error) int {
+	switch utils.ErrorCode(err) {
+	case utils.CodeNotFound:
+		return http.StatusNotFound
+	case utils.CodeConflict:
+		return http.StatusConflict
+	case utils.CodeUnauthorized:
+		return http.StatusUnauthorized
+	case utils.CodeForbidden:
+		return http.StatusForbidden
+	case "", utils.CodeInternal:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// statusCode возвращает код ошибки по HTTP-коду состояния для ошибок без собственного кода.
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return utils.CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return utils.CodeUnauthorized
+	case http.StatusForbidden:
+		return utils.CodeForbidden
+	case http.StatusNotFound:
+		return utils.CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return utils.CodeMethodNotAllowed
+	case http.StatusConflict:
+		return utils.CodeConflict
+	case http.StatusTooManyRequests:
+		return utils.CodeTooManyRequests
+	default:
+		return utils.CodeInternal
+	}
+}
+
+// sendJSON сериализует value в JSON и записывает его в ответ со статусом 200.
+// Если сериализовать значение не удалось, клиенту отправляется ошибка 500.
+func (h *Handler) sendJSON(w http.ResponseWriter, r *http.Request, value any) {
+	response, err := json.Marshal(value)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_, err = w.Write(response)
+	if err != nil {
+		h.logger.Error(err)
+	}
 }
 
 func (h *Handler) GetID(r *http.Request) (int, error) {
@@ -52,12 +162,12 @@ func (h *Handler) GetID(r *http.Request) (int, error) {
 	var err error
 	idStr := r.FormValue("id")
 	if idStr == "" {
-		return 0, fmt.Errorf("id is empty")
+		return 0, utils.NewError(utils.CodeInvalidID, "id", "не указан id")
 	}
 	if idStr != "" || len(idStr) != 0 {
 		id, err = strconv.Atoi(idStr)
 		if err != nil {
-			return 0, fmt.Errorf("can not parse ID")
+			return 0, utils.NewError(utils.CodeInvalidID, "id", "не удаётся разобрать id")
 		}
 	}
 	return id, nil
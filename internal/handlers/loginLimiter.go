@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go_final_project/internal/models"
+)
+
+// lockPolicy описывает, после скольких неудачных попыток ключ блокируется и на какое время.
+// Каждая следующая неудачная попытка после порога удваивает время блокировки, но не больше max.
+type lockPolicy struct {
+	threshold int
+	base      time.Duration
+	max       time.Duration
+}
+
+var (
+	// loginPolicy блокирует подбор пароля к одной учётной записи (или к общему паролю) с одного адреса.
+	loginPolicy = lockPolicy{threshold: 5, base: 30 * time.Second, max: time.Hour}
+	// ipPolicy блокирует адрес, с которого перебирают пароли к разным учётным записям.
+	ipPolicy = lockPolicy{threshold: 20, base: 30 * time.Second, max: time.Hour}
+	// attemptWindow - время без неудачных попыток, после которого счётчик сбрасывается.
+	attemptWindow = 15 * time.Minute
+	// alertThreshold - число неудачных попыток со всех адресов за attemptWindow, после которого в журнал пишется
+	// предупреждение. Общий счётчик вход не блокирует: иначе любой клиент мог бы закрыть вход всем пользователям.
+	alertThreshold = 100
+)
+
+// lockDuration возвращает время блокировки после failures неудачных попыток или 0, если порог не достигнут.
+func (p lockPolicy) lockDuration(failures int) time.Duration {
+	if failures < p.threshold {
+		return 0
+	}
+	duration := p.base
+	for i := p.threshold; i < failures && duration < p.max; i++ {
+		duration *= 2
+	}
+	return min(duration, p.max)
+}
+
+// loginLimiter ограничивает частоту попыток входа по IP-адресу и по паре из IP-адреса и логина.
+// Счётчики хранятся в памяти и, если передано хранилище, дублируются в таблицу login_attempts.
+// Неудачные попытки со всех адресов только подсчитываются для предупреждения в журнале.
+type loginLimiter struct {
+	mu        sync.Mutex
+	attempts  map[string]*models.LoginAttempt
+	db        models.AccountStore
+	logger    *zap.SugaredLogger
+	lastPrune time.Time
+	// total - неудачные попытки со всех адресов с момента since.
+	total int
+	since time.Time
+}
+
+// newLoginLimiter создаёт ограничитель попыток входа.
+// Если persist равен true, счётчики загружаются из базы данных и сохраняются в неё при каждом изменении.
+//
+// Параметры:
+// - db: Хранилище счётчиков.
+// - logger: Журнал для записи событий блокировки.
+// - persist: Флаг сохранения счётчиков в базе данных.
+//
+// Возвращает:
+// - Указатель на новый ограничитель.
+func newLoginLimiter(db models.AccountStore, logger *zap.SugaredLogger, persist bool) *loginLimiter {
+	l := &loginLimiter{
+		attempts:  make(map[string]*models.LoginAttempt),
+		logger:    logger,
+		lastPrune: time.Now(),
+	}
+	if !persist {
+		return l
+	}
+	l.db = db
+	saved, err := db.LoadLoginAttempts()
+	if err != nil {
+		logger.Errorw("error loading login attempts", "error", err)
+		return l
+	}
+	for i := range saved {
+		l.attempts[saved[i].Key] = &saved[i]
+	}
+	return l
+}
+
+// check проверяет, заблокированы ли попытки входа с адреса ip под логином login.
+//
+// Параметры:
+// - ip: IP-адрес клиента.
+// - login: Логин из запроса; пустая строка - вход по общему паролю.
+//
+// Возвращает:
+// - Время, через которое можно повторить попытку, или 0, если вход разрешён.
+func (l *loginLimiter) check(ip, login string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	var wait time.Duration
+	for _, key := range []string{ipKey(ip), loginKey(ip, login)} {
+		if attempt, ok := l.attempts[key]; ok && now.Before(attempt.LockedUntil) {
+			wait = max(wait, attempt.LockedUntil.Sub(now))
+		}
+	}
+	return wait
+}
+
+// failure учитывает неудачную попытку входа с адреса ip под логином login в счётчике адреса,
+// в счётчике пары адреса и логина и в общем счётчике.
+func (l *loginLimiter) failure(ip, login string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.prune(now)
+	l.register(loginKey(ip, login), loginPolicy, now)
+	l.register(ipKey(ip), ipPolicy, now)
+	l.count(now)
+}
+
+// success сбрасывает счётчик пары адреса ip и логина login после успешного входа.
+// Счётчик адреса не сбрасывается, чтобы вход в свою учётную запись не открывал перебор паролей к чужим.
+func (l *loginLimiter) success(ip, login string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := loginKey(ip, login)
+	if _, ok := l.attempts[key]; !ok {
+		return
+	}
+	delete(l.attempts, key)
+	l.persistDelete(key)
+}
+
+// register увеличивает счётчик key и при достижении порога блокирует ключ по политике policy.
+func (l *loginLimiter) register(key string, policy lockPolicy, now time.Time) {
+	attempt, ok := l.attempts[key]
+	if !ok || now.Sub(attempt.LastFailure) > attemptWindow && now.After(attempt.LockedUntil) {
+		attempt = &models.LoginAttempt{Key: key}
+		l.attempts[key] = attempt
+	}
+	attempt.Failures++
+	attempt.LastFailure = now
+	if lock := policy.lockDuration(attempt.Failures); lock > 0 {
+		attempt.LockedUntil = now.Add(lock)
+		l.logger.Warnw("signin locked out", "key", key, "failures", attempt.Failures, "until", attempt.LockedUntil)
+	}
+	if l.db != nil {
+		if err := l.db.SaveLoginAttempt(*attempt); err != nil {
+			l.logger.Errorw("error saving login attempt", "error", err)
+		}
+	}
+}
+
+// count учитывает неудачную попытку в общем счётчике и пишет в журнал предупреждение,
+// когда за attemptWindow набирается alertThreshold неудачных попыток со всех адресов.
+func (l *loginLimiter) count(now time.Time) {
+	if now.Sub(l.since) > attemptWindow {
+		l.total = 0
+		l.since = now
+	}
+	l.total++
+	if l.total == alertThreshold {
+		l.logger.Warnw("signin failures from all addresses exceed threshold", "failures", l.total, "since", l.since)
+	}
+}
+
+// prune удаляет устаревшие счётчики не чаще одного раза за attemptWindow.
+func (l *loginLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < attemptWindow {
+		return
+	}
+	l.lastPrune = now
+	for key, attempt := range l.attempts {
+		if now.Sub(attempt.LastFailure) > attemptWindow && now.After(attempt.LockedUntil) {
+			delete(l.attempts, key)
+			l.persistDelete(key)
+		}
+	}
+}
+
+// persistDelete удаляет сохранённый счётчик key, если включено сохранение в базе данных.
+func (l *loginLimiter) persistDelete(key string) {
+	if l.db == nil {
+		return
+	}
+	if err := l.db.DeleteLoginAttempt(key); err != nil {
+		l.logger.Errorw("error deleting login attempt", "error", err)
+	}
+}
+
+// ipKey возвращает ключ счётчика для IP-адреса.
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginKey возвращает ключ счётчика пары IP-адреса и логина. Логин хешируется, чтобы ключ помещался в столбец
+// login_attempts.key и логины не сохранялись в таблице счётчиков.
+func loginKey(ip, login string) string {
+	sum := sha256.Sum256([]byte(ip + "\x00" + login))
+	return "login:" + hex.EncodeToString(sum[:16])
+}
+
+// clientIP возвращает IP-адрес клиента без номера порта.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
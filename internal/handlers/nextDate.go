@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 	"time"
 
@@ -13,20 +12,21 @@ import (
 // и частоту повторения в параметре "repeat".
 //
 // Функция использует предоставленную текущую дату и время для определения следующего вхождения целевой даты.
-// Параметр "repeat" может принимать одно из следующих значений: "daily", "weekly", "monthly", или "yearly".
+// Параметр "repeat" принимает правила "d", "w", "m", "y" или правило RFC 5545, начинающееся с "RRULE:".
 //
 // Если входные параметры недействительны или при вычислении возникает ошибка, функция возвращает ответ HTTP 400 Bad Request.
 // В противном случае она устанавливает заголовок "Content-Type" в "text/plain" и выводит результат в формате "%s\n".
 func (h *Handler) NextDate(w http.ResponseWriter, r *http.Request) {
 	if r == nil {
-		err := fmt.Errorf("request is nil")
-		h.SendErr(w, err, http.StatusBadRequest)
+		err := utils.Errorf("пустой запрос")
+		h.SendErr(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	now, err := time.Parse("20060102", r.FormValue("now"))
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, utils.NewError(utils.CodeInvalidDate, "now", "неверный формат now: %s", r.FormValue("now")).WithDetail("format", "20060102"),
+			http.StatusBadRequest)
 		return
 	}
 
@@ -34,14 +34,14 @@ func (h *Handler) NextDate(w http.ResponseWriter, r *http.Request) {
 	repeat := r.FormValue("repeat")
 
 	if date == "" || repeat == "" {
-		err = fmt.Errorf("date or repeat is empty")
-		h.SendErr(w, err, http.StatusBadRequest)
+		err = utils.Errorf("не указаны date или repeat")
+		h.SendErr(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	next, err := utils.NextDate(now, date, repeat)
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, err, http.StatusBadRequest)
 		return
 	}
 
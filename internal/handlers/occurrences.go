@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_final_project/internal/utils"
+)
+
+// defaultOccurrences - сколько дат возвращает /api/occurrences, если параметр count не указан.
+const defaultOccurrences = 10
+
+// occurrencesResponse - ответ /api/occurrences: описание правила и ближайшие даты повторения.
+type occurrencesResponse struct {
+	Repeat      string   `json:"repeat"`
+	Description string   `json:"description"`
+	Dates       []string `json:"dates"`
+}
+
+// Occurrences обрабатывает GET-запрос /api/occurrences?date=<дата>&repeat=<правило>&from=<дата>&count=<N>
+// и возвращает ближайшие даты повторения, вычисленные так же, как /api/nextdate, вместе с описанием правила.
+// Параметр from по умолчанию - текущая дата, date - дата from, count - 10 (не больше utils.MaxOccurrences).
+// Описание правила составляется на языке запроса (см. language).
+//
+// Параметры:
+// - w: http.ResponseWriter для записи ответа.
+// - r: http.Request, содержащий данные запроса.
+//
+// Возвращает:
+// - Записывает JSON-ответ с описанием правила и списком дат в ответный writer.
+// - Если параметры неверны, отправляет ответ с ошибкой с кодом состояния 400.
+func (h *Handler) Occurrences(w http.ResponseWriter, r *http.Request) {
+	from := h.clock.Now()
+	if value := r.FormValue("from"); value != "" {
+		var err error
+		from, err = time.Parse("20060102", value)
+		if err != nil {
+			h.SendErr(w, r, utils.NewError(utils.CodeInvalidDate, "from", "неверный формат from: %s", value).WithDetail("format", "20060102"),
+				http.StatusBadRequest)
+			return
+		}
+	}
+	date := r.FormValue("date")
+	if date == "" {
+		date = from.Format("20060102")
+	}
+	count := defaultOccurrences
+	if value := r.FormValue("count"); value != "" {
+		var err error
+		count, err = strconv.Atoi(value)
+		if err != nil {
+			h.SendErr(w, r, utils.NewError(utils.CodeInvalidRequest, "count", "неверное значение count: %s", value), http.StatusBadRequest)
+			return
+		}
+	}
+	repeat := r.FormValue("repeat")
+	dates, err := utils.Occurrences(from, date, repeat, count)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	description, err := utils.DescribeRepeat(repeat, language(r))
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	h.sendJSON(w, r, occurrencesResponse{Repeat: repeat, Description: description, Dates: dates})
+}
@@ -1,56 +1,187 @@
 package handlers
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"math"
 	"net/http"
-	"os"
+	"strconv"
+	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
+	"go_final_project/internal/models"
+	"go_final_project/internal/utils"
 )
 
+// tokenResponse - тело ответа обработчиков /api/signin и /api/refresh.
+type tokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// credentials - тело запросов /api/signin и /api/signup.
+type credentials struct {
+	Login string `json:"login"`
+	Pass  string `json:"password"`
+}
+
+// Authentication проверяет учётные данные и выдаёт пару токенов: токен доступа с ограниченным временем жизни
+// и токен обновления, по которому через /api/refresh можно получить новую пару.
+// Если поле "login" не указано, пароль сравнивается с общим паролем TODO_PASSWORD и токены выдаются пользователю по умолчанию.
+// Иначе пароль проверяется по таблице users, а идентификатор пользователя записывается в claim "sub".
+// После серии неудачных попыток с адреса клиента под одним логином (или с общим паролем) временно блокируется
+// эта пара, а после серии неудачных попыток под разными логинами - весь адрес; сервер отвечает 429 с заголовком Retry-After.
 func (h *Handler) Authentication(w http.ResponseWriter, r *http.Request) {
 	// Получаем пароль из переменной окружения
-	password := os.Getenv("TODO_PASSWORD")
-	var request struct {
-		Pass string `json:"password"`
-	}
+	password := utils.CheckPassword()
+	ip := clientIP(r)
+	var request credentials
 	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, errBadBody, http.StatusBadRequest)
+		return
+	}
+	if wait := h.limiter.check(ip, request.Login); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		h.SendErr(w, r, utils.Errorf("слишком много попыток входа"), http.StatusTooManyRequests)
+		return
+	}
+	if request.Login == "" {
+		if password != "" && password != request.Pass {
+			h.limiter.failure(ip, request.Login)
+			err := utils.Errorf("неверный пароль")
+			h.SendErr(w, r, err, http.StatusUnauthorized)
+			return
+		}
+		h.limiter.success(ip, request.Login)
+		h.sendTokens(w, r, password, models.User{ID: models.DefaultUserID})
+		return
+	}
+	user, err := h.db.Authenticate(request.Login, request.Pass)
+	if errors.Is(err, models.ErrInvalidCredentials) {
+		h.limiter.failure(ip, request.Login)
+		h.SendErr(w, r, err, http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusInternalServerError)
 		return
 	}
-	var hashedPass string
+	h.limiter.success(ip, request.Login)
+	h.sendTokens(w, r, password, *user)
+}
+
+// SignUp регистрирует нового пользователя с полями "login" и "password" и сразу выдаёт ему пару токенов.
+// Учётные записи доступны только при включённой аутентификации (задан TODO_PASSWORD).
+func (h *Handler) SignUp(w http.ResponseWriter, r *http.Request) {
+	password := utils.CheckPassword()
 	if password == "" {
-		hashString := sha256.Sum256([]byte(""))
-		hashedPass = hex.EncodeToString(hashString[:])
-	} else if password != request.Pass {
-		err := errors.New("password is incorrect")
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, utils.Errorf("аутентификация отключена"), http.StatusForbidden)
+		return
+	}
+	var request credentials
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		h.SendErr(w, r, errBadBody, http.StatusBadRequest)
+		return
+	}
+	request.Login = strings.TrimSpace(request.Login)
+	if request.Login == "" || len(request.Login) > 64 {
+		h.SendErr(w, r, utils.NewError(utils.CodeInvalidRequest, "login", "имя пользователя должно содержать от 1 до 64 символов"), http.StatusBadRequest)
 		return
 	}
-	if len(password) > 0 {
-		hashString := sha256.Sum256([]byte(request.Pass))
-		hashedPass = hex.EncodeToString(hashString[:])
+	if len(request.Pass) < 8 {
+		h.SendErr(w, r, utils.NewError(utils.CodeInvalidRequest, "password", "пароль должен содержать не меньше 8 символов"), http.StatusBadRequest)
+		return
 	}
-	claims := jwt.MapClaims{
-		"hashedPass": hashedPass,
+	user, err := h.db.CreateUser(request.Login, request.Pass)
+	if errors.Is(err, models.ErrUserExists) {
+		h.SendErr(w, r, err, http.StatusConflict)
+		return
 	}
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	h.sendTokens(w, r, password, *user)
+}
 
-	// получаем подписанный токен
-	signedToken, err := jwtToken.SignedString([]byte(password))
+// Refresh принимает токен обновления в поле "refresh_token" и выдаёт новую пару токенов.
+// Использованный токен обновления отзывается, поэтому повторно его применить нельзя.
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	password := utils.CheckPassword()
+	var request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		h.SendErr(w, r, errBadBody, http.StatusBadRequest)
+		return
+	}
+	if request.RefreshToken == "" {
+		h.SendErr(w, r, utils.NewError(utils.CodeInvalidRequest, "refresh_token", "не указан refresh_token"), http.StatusBadRequest)
+		return
+	}
+	claims, err := h.validateToken(request.RefreshToken, password, refreshToken)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusUnauthorized)
+		return
+	}
+	err = h.revokeClaims(claims)
 	if err != nil {
-		err = fmt.Errorf("failed to sign jwt: %s", err)
-		h.SendErr(w, err, http.StatusInternalServerError)
+		h.SendErr(w, r, err, http.StatusInternalServerError)
 		return
 	}
+	h.sendTokens(w, r, password, userFromClaims(claims))
+}
+
+// SignOut отзывает токен доступа из запроса и, если он передан в поле "refresh_token", токен обновления.
+// Недействительные и просроченные токены пропускаются. Cookie "token" очищается.
+func (h *Handler) SignOut(w http.ResponseWriter, r *http.Request) {
+	password := utils.CheckPassword()
+	var request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	// Тело запроса необязательно
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	tokens := map[string]string{accessToken: tokenFromRequest(r), refreshToken: request.RefreshToken}
+	for tokenType, token := range tokens {
+		if token == "" {
+			continue
+		}
+		claims, err := h.validateToken(token, password, tokenType)
+		if err != nil {
+			continue
+		}
+		err = h.revokeClaims(claims)
+		if err != nil {
+			h.SendErr(w, r, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: "token", Value: "", Path: "/", MaxAge: -1})
+	h.logger.Infof("sent response via handler SignOut")
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_, err := w.Write([]byte("{}"))
+	if err != nil {
+		h.logger.Error(err)
+	}
+}
 
+// sendTokens выпускает новую пару токенов пользователя user и записывает её в ответ в формате JSON.
+func (h *Handler) sendTokens(w http.ResponseWriter, r *http.Request, password string, user models.User) {
+	access, refresh, err := h.issueTokens(password, user)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	response, err := json.Marshal(tokenResponse{Token: access, RefreshToken: refresh})
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusInternalServerError)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	_, err = fmt.Fprintf(w, `{"token": "%s"}`, signedToken)
+	_, err = w.Write(response)
 	if err != nil {
 		h.logger.Error(err)
 	}
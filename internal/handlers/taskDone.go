@@ -1,12 +1,27 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"go_final_project/internal/utils"
 )
 
+// maxNoteLength - максимальная длина заметки к выполнению задачи в символах.
+const maxNoteLength = 1000
+
 // TaskDone обрабатывает завершение задачи. Если задача повторяется, она обновляет дату для следующего повторения.
-// В противном случае, она удаляет задачу из планировщика.
+// В противном случае, она отмечает задачу выполненной: задача пропадает из списка /api/tasks,
+// но остаётся доступной с параметром status=done.
+// Необязательный параметр version задаёт ожидаемую версию задачи, а параметр date - дату повторения, которую
+// видел клиент: если задачу уже изменил или перенёс другой запрос, возвращается ошибка 409. Без них повторное
+// нажатие «выполнено» перенесёт задачу ещё раз, поэтому веб-интерфейс передаёт date.
+// Необязательная заметка к записи журнала выполнения передаётся в параметре note или в теле запроса {"note": "..."}.
 //
 // Параметры:
 // - w: http.ResponseWriter для записи ответа.
@@ -14,21 +29,68 @@ import (
 //
 // Возвращает:
 // - Записывает JSON-ответ с пустым объектом в ответный writer.
-// - Если во время процесса возникает ошибка, он отправляет ответ с ошибкой с соответствующим кодом состояния.
+// - Если во время процесса возникает ошибка, он отправляет ответ с ошибкой с соответствующим кодом состояния:
+// 400 для неверного запроса или правила повторения, 404 для несуществующей задачи, 409 для конфликта версий.
 func (h *Handler) TaskDone(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		err := errors.New("request method must be post")
-		h.SendErr(w, err, http.StatusMethodNotAllowed)
+		err := utils.Errorf("метод запроса должен быть POST")
+		h.SendErr(w, r, err, http.StatusMethodNotAllowed)
 		return
 	}
 	id, err := h.GetID(r)
 	if err != nil {
-		h.SendErr(w, err, http.StatusBadRequest)
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	var version int
+	if versionStr := r.FormValue("version"); versionStr != "" {
+		version, err = strconv.Atoi(versionStr)
+		if err != nil {
+			h.SendErr(w, r, utils.NewError(utils.CodeInvalidRequest, "version", "не удаётся разобрать version"), http.StatusBadRequest)
+			return
+		}
+	}
+	date := r.FormValue("date")
+	if date != "" {
+		if _, err := time.Parse("20060102", date); err != nil {
+			err = utils.NewError(utils.CodeInvalidDate, "date", "неверный формат даты %s", date).WithDetail("format", "20060102")
+			h.SendErr(w, r, err, http.StatusBadRequest)
+			return
+		}
+	}
+	note, err := doneNote(r)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	err = h.db.Done(userID(r), id, version, date, note)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
 	}
-	h.db.Done(id)
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	_, err = w.Write([]byte("{}"))
 	if err != nil {
 		h.logger.Error(err)
 	}
 }
+
+// doneNote извлекает заметку к выполнению задачи из параметра note или из JSON-тела запроса.
+// Пустое тело допускается и означает отсутствие заметки.
+func doneNote(r *http.Request) (string, error) {
+	note := r.FormValue("note")
+	if note == "" && r.Body != nil {
+		var body struct {
+			Note string `json:"note"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", errBadBody
+		}
+		note = body.Note
+	}
+	if utf8.RuneCountInString(note) > maxNoteLength {
+		return "", utils.NewError(utils.CodeInvalidRequest, "note", "слишком длинная заметка")
+	}
+	return note, nil
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"go_final_project/internal/models"
+)
+
+// historyResponse - ответ /api/task/history: журнал выполнения задачи и счётчики по нему.
+type historyResponse struct {
+	ID          string                 `json:"id"`
+	Completions []models.Completion    `json:"completions"`
+	Stats       models.CompletionStats `json:"stats"`
+}
+
+// TaskHistory обрабатывает GET-запрос /api/task/history?id=<id> и возвращает журнал выполнения задачи
+// от новых записей к старым вместе со счётчиками: числом выполнений, долей выполненных вовремя
+// от числа повторений по правилу задачи и сериями.
+//
+// Параметры:
+// - w: http.ResponseWriter для записи ответа.
+// - r: http.Request, содержащий данные запроса.
+//
+// Возвращает:
+// - Записывает JSON-ответ с журналом выполнения в ответный writer.
+// - Если во время процесса возникает ошибка, он отправляет ответ с ошибкой с соответствующим кодом состояния:
+// 400 для неверного идентификатора, 404 для несуществующей задачи.
+func (h *Handler) TaskHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := h.GetID(r)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	task, err := h.db.GetTask(userID(r), id)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	exceptions, err := h.db.Exceptions(userID(r), id)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	task.Exceptions = make(map[string]bool, len(exceptions))
+	for _, date := range exceptions {
+		task.Exceptions[date] = true
+	}
+	completions, err := h.db.History(userID(r), id)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	h.sendJSON(w, r, historyResponse{
+		ID:          strconv.Itoa(id),
+		Completions: completions,
+		Stats:       models.Stats(*task, completions, h.clock.Now()),
+	})
+}
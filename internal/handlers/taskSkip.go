@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_final_project/internal/utils"
+)
+
+// skipsResponse - ответ GET /api/task/skip: даты-исключения задачи.
+type skipsResponse struct {
+	ID    string   `json:"id"`
+	Dates []string `json:"dates"`
+}
+
+// SkipTask обрабатывает POST-запрос /api/task/skip?id=<id>[&date=<дата>] и добавляет повторяющейся задаче
+// дату-исключение. Без параметра date пропускается текущее повторение: задача переносится на следующую дату,
+// а в журнал выполнения ничего не записывается.
+//
+// Параметры:
+// - w: http.ResponseWriter для записи ответа.
+// - r: http.Request, содержащий данные запроса.
+//
+// Возвращает:
+// - Записывает JSON-ответ с пустым объектом в ответный writer.
+// - Если во время процесса возникает ошибка, он отправляет ответ с ошибкой с соответствующим кодом состояния:
+// 400 для неверного запроса или неповторяющейся задачи, 404 для несуществующей задачи, 409 для выполненной задачи.
+func (h *Handler) SkipTask(w http.ResponseWriter, r *http.Request) {
+	id, err := h.GetID(r)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	date, err := skipDate(r, false)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	err = h.db.Skip(userID(r), id, date)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	h.sendJSON(w, r, struct{}{})
+}
+
+// GetSkips обрабатывает GET-запрос /api/task/skip?id=<id> и возвращает даты-исключения задачи в порядке возрастания.
+//
+// Параметры:
+// - w: http.ResponseWriter для записи ответа.
+// - r: http.Request, содержащий данные запроса.
+//
+// Возвращает:
+// - Записывает JSON-ответ со списком дат в ответный writer.
+// - Если во время процесса возникает ошибка, он отправляет ответ с ошибкой с соответствующим кодом состояния:
+// 400 для неверного идентификатора, 404 для несуществующей задачи.
+func (h *Handler) GetSkips(w http.ResponseWriter, r *http.Request) {
+	id, err := h.GetID(r)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	dates, err := h.db.Exceptions(userID(r), id)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	h.sendJSON(w, r, skipsResponse{ID: strconv.Itoa(id), Dates: dates})
+}
+
+// DeleteSkip обрабатывает DELETE-запрос /api/task/skip?id=<id>&date=<дата> и удаляет дату-исключение задачи.
+// Дата задачи при этом не меняется.
+//
+// Параметры:
+// - w: http.ResponseWriter для записи ответа.
+// - r: http.Request, содержащий данные запроса.
+//
+// Возвращает:
+// - Записывает JSON-ответ с пустым объектом в ответный writer.
+// - Если во время процесса возникает ошибка, он отправляет ответ с ошибкой с соответствующим кодом состояния:
+// 400 для неверного запроса, 404 для несуществующей задачи или даты-исключения.
+func (h *Handler) DeleteSkip(w http.ResponseWriter, r *http.Request) {
+	id, err := h.GetID(r)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	date, err := skipDate(r, true)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	err = h.db.DeleteException(userID(r), id, date)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	h.sendJSON(w, r, struct{}{})
+}
+
+// skipDate читает и проверяет параметр date запроса в формате "20060102"; required требует его наличия.
+func skipDate(r *http.Request, required bool) (string, error) {
+	date := r.FormValue("date")
+	if date == "" {
+		if required {
+			return "", utils.NewError(utils.CodeInvalidDate, "date", "не указана дата")
+		}
+		return "", nil
+	}
+	if _, err := time.Parse("20060102", date); err != nil {
+		return "", utils.NewError(utils.CodeInvalidDate, "date", "неверный формат даты %s", date).WithDetail("format", "20060102")
+	}
+	return date, nil
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go_final_project/internal/utils"
+)
+
+// TaskUndone обрабатывает повторное открытие выполненной задачи: снимает с неё отметку о выполнении,
+// и задача снова появляется в списке /api/tasks.
+//
+// Параметры:
+// - w: http.ResponseWriter для записи ответа.
+// - r: http.Request, содержащий данные запроса.
+//
+// Возвращает:
+// - Записывает JSON-ответ с пустым объектом в ответный writer.
+// - Если во время процесса возникает ошибка, он отправляет ответ с ошибкой с соответствующим кодом состояния:
+// 400 для неверного запроса, 404 для несуществующей задачи, 409 для невыполненной задачи.
+func (h *Handler) TaskUndone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		err := utils.Errorf("метод запроса должен быть POST")
+		h.SendErr(w, r, err, http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := h.GetID(r)
+	if err != nil {
+		h.SendErr(w, r, err, http.StatusBadRequest)
+		return
+	}
+	err = h.db.Undone(userID(r), id)
+	if err != nil {
+		h.SendErr(w, r, err, errStatus(err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	_, err = w.Write([]byte("{}"))
+	if err != nil {
+		h.logger.Error(err)
+	}
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Ограничения на окно повестки.
+const (
+	// DefaultAgendaDays - сколько дней, начиная с сегодняшнего, охватывает повестка, если days не указан.
+	DefaultAgendaDays = 14
+	// MaxAgendaDays - наибольшее окно повестки в днях.
+	MaxAgendaDays = 92
+)
+
+// Agenda - активные задачи пользователя, разложенные по срокам. Повторяющаяся задача попадает в повестку
+// каждым своим повторением в окне From-To; у повторения те же поля, что у задачи, кроме даты.
+// В повестку попадает не больше MaxExpanded самых ранних повторений.
+type Agenda struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Overdue - задачи с датой раньше сегодняшней, каждая один раз, с сохранённой датой.
+	Overdue []Task `json:"overdue"`
+	Today   []Task `json:"today"`
+	// Tomorrow - повторения завтрашнего дня, даже если завтра уже следующая неделя.
+	Tomorrow []Task `json:"tomorrow"`
+	// ThisWeek - повторения после завтрашнего дня до воскресенья текущей недели.
+	ThisWeek []Task `json:"this_week"`
+	// Later - повторения после текущей недели до конца окна.
+	Later []Task `json:"later"`
+	// NoDate - задачи без даты или с датой, которую не удаётся разобрать.
+	NoDate []Task `json:"no_date"`
+	// Truncated - в окне есть повторения сверх MaxExpanded, в повестку попали только самые ранние.
+	Truncated bool `json:"truncated"`
+}
+
+// NewAgenda создаёт пустую повестку с окном в days дней, начиная с сегодняшнего.
+//
+// Параметры:
+// now - текущий момент; сегодняшний день определяется по нему в часовом поясе по умолчанию, как для условия overdue поиска.
+// days - размер окна в днях от 1 до MaxAgendaDays.
+//
+// Возвращает:
+// Повестку без задач.
+func NewAgenda(now time.Time, days int) Agenda {
+	today := Task{}.local(now)
+	return Agenda{From: today.Format("20060102"), To: today.AddDate(0, 0, days-1).Format("20060102"),
+		Overdue: []Task{}, Today: []Task{}, Tomorrow: []Task{}, ThisWeek: []Task{}, Later: []Task{}, NoDate: []Task{}}
+}
+
+// Fill раскладывает задачи по срокам повестки. Повторяющиеся задачи разворачиваются в повторения функцией expandAll,
+// внутри каждого срока задачи упорядочиваются по дате, времени и идентификатору.
+//
+// Параметры:
+// tasks - активные задачи, которые могут попасть в окно, с загруженными датами-исключениями (см. TaskStore.Between).
+func (a *Agenda) Fill(tasks []Task) {
+	const dateFormat = "20060102"
+	today, err := time.Parse(dateFormat, a.From)
+	if err != nil {
+		return
+	}
+	day := func(n int) string {
+		return today.AddDate(0, 0, n).Format(dateFormat)
+	}
+	// до воскресенья: в Go неделя начинается с воскресенья, в повестке - с понедельника
+	sunday := (7 - int(today.Weekday())) % 7
+	dated := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		if _, err := time.Parse(dateFormat, task.Date); err != nil {
+			a.NoDate = append(a.NoDate, task)
+			continue
+		}
+		if task.Date < a.From {
+			a.Overdue = append(a.Overdue, task)
+		}
+		dated = append(dated, task)
+	}
+	var occurrences []Task
+	occurrences, a.Truncated = expandAll(dated, a.From, a.To, MaxExpanded)
+	for _, occurrence := range occurrences {
+		switch {
+		case occurrence.Date == day(0):
+			a.Today = append(a.Today, occurrence)
+		case occurrence.Date == day(1):
+			a.Tomorrow = append(a.Tomorrow, occurrence)
+		case occurrence.Date <= day(sunday):
+			a.ThisWeek = append(a.ThisWeek, occurrence)
+		default:
+			a.Later = append(a.Later, occurrence)
+		}
+	}
+	sortByDate(a.Overdue)
+	sortByDate(a.NoDate)
+}
+
+// sortByDate упорядочивает задачи так же, как список задач с сортировкой SortDate.
+func sortByDate(tasks []Task) {
+	query := TaskQuery{Sort: SortDate}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return query.compare(query.keyOf(tasks[i]), query.keyOf(tasks[j])) < 0
+	})
+}
+
+// Between возвращает активные задачи пользователя, у которых могут быть повторения с датами от from до to
+// включительно: задачи с датой в окне и повторяющиеся задачи с датой до окна. Пустой from включает все задачи
+// с датой до to. У повторяющихся задач загружаются даты-исключения в окне.
+//
+// Параметры:
+// - userID: Идентификатор владельца задач.
+// - from, to: Границы окна в формате "20060102".
+//
+// Возвращает:
+// - Задачи и ошибку, если во время извлечения произошла ошибка.
+func (c *DBConnection) Between(userID int64, from, to string) ([]Task, error) {
+	rows, err := c.query(`SELECT `+taskColumns+` FROM scheduler WHERE user_id = ?`+StatusActive.clause()+
+		` AND date <= ? AND (date >= ? OR repeat <> '') ORDER BY date, id`, userID, to, from)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := scanTasks(rows, false)
+	if err != nil {
+		return nil, err
+	}
+	rows, err = c.query(`SELECT task_id, date FROM task_exceptions WHERE user_id = ? AND date >= ? AND date <= ?`,
+		userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	exceptions := make(map[string]map[string]bool)
+	for rows.Next() {
+		var id, date string
+		if err := rows.Scan(&id, &date); err != nil {
+			return nil, err
+		}
+		if exceptions[id] == nil {
+			exceptions[id] = make(map[string]bool)
+		}
+		exceptions[id][date] = true
+	}
+	for i := range tasks {
+		tasks[i].Exceptions = exceptions[tasks[i].ID]
+	}
+	return tasks, rows.Err()
+}
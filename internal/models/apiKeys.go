@@ -0,0 +1,191 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go_final_project/internal/utils"
+)
+
+// Области действия API-ключей.
+const (
+	ScopeRead      = "read"
+	ScopeReadWrite = "read-write"
+)
+
+// apiKeyPrefix - префикс, с которого начинаются все API-ключи.
+const apiKeyPrefix = "td_"
+
+var ErrInvalidAPIKey = utils.NewError(utils.CodeUnauthorized, "", "неверный API-ключ")
+
+// APIKey - описание долгоживущего API-ключа. Сам ключ не хранится, в базе данных лежит только его хэш.
+type APIKey struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"-"`
+	Name       string `json:"name"`
+	Prefix     string `json:"prefix"`
+	Scope      string `json:"scope"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+}
+
+// CreateAPIKey создаёт новый API-ключ пользователя.
+//
+// Параметры:
+// - userID: Идентификатор владельца ключа.
+// - name: Произвольное название ключа.
+// - scope: Область действия ключа (ScopeRead или ScopeReadWrite).
+//
+// Возвращает:
+// - Описание ключа, сам ключ (он возвращается только один раз) и ошибку, если ключ не удалось создать.
+func (c *DBConnection) CreateAPIKey(userID int64, name, scope string) (*APIKey, string, error) {
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", err
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(random)
+	now := c.clock.Now()
+	key := APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    secret[len(apiKeyPrefix) : len(apiKeyPrefix)+8],
+		Scope:     scope,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+	err := c.queryRow(`INSERT INTO api_keys (user_id, name, prefix, key_hash, scope, created_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`,
+		userID, name, key.Prefix, hashAPIKey(secret), scope, now.Unix()).Scan(&key.ID)
+	if err != nil {
+		c.logger.Errorw("error inserting api key", "error", err)
+		return nil, "", err
+	}
+	c.logger.Infof("api key %d created for user %d", key.ID, userID)
+	return &key, secret, nil
+}
+
+// ListAPIKeys извлекает все API-ключи пользователя.
+//
+// Параметры:
+// - userID: Идентификатор владельца ключей.
+//
+// Возвращает:
+// - Срез ключей и ошибку, если во время извлечения произошла ошибка.
+func (c *DBConnection) ListAPIKeys(userID int64) ([]APIKey, error) {
+	rows, err := c.query(`SELECT id, user_id, name, prefix, scope, created_at, last_used_at FROM api_keys
+	WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// DeleteAPIKey отзывает API-ключ пользователя.
+//
+// Параметры:
+// - userID: Идентификатор владельца ключа.
+// - id: Идентификатор ключа.
+//
+// Возвращает:
+// - Ошибку, если ключ не найден или удаление завершилось неудачно.
+func (c *DBConnection) DeleteAPIKey(userID, id int64) error {
+	res, err := c.exec(`DELETE FROM api_keys WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+	num, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if num != 1 {
+		return ErrNotFound
+	}
+	c.logger.Infof("api key %d revoked", id)
+	return nil
+}
+
+// PruneAPIKeys удаляет ключи пользователя, которые не использовались с момента before.
+// Ключи, которые ни разу не использовались, сравниваются по времени создания.
+//
+// Параметры:
+// - userID: Идентификатор владельца ключей.
+// - before: Граница времени последнего использования.
+//
+// Возвращает:
+// - Количество удалённых ключей и ошибку, если удаление завершилось неудачно.
+func (c *DBConnection) PruneAPIKeys(userID int64, before time.Time) (int64, error) {
+	res, err := c.exec(`DELETE FROM api_keys WHERE user_id = ? AND COALESCE(last_used_at, created_at) < ?`,
+		userID, before.Unix())
+	if err != nil {
+		return 0, err
+	}
+	num, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	c.logger.Infof("%d stale api keys of user %d pruned", num, userID)
+	return num, nil
+}
+
+// UseAPIKey находит ключ по его значению и отмечает время его использования.
+//
+// Параметры:
+// - secret: Значение ключа из заголовка X-API-Key.
+//
+// Возвращает:
+// - Описание ключа и ErrInvalidAPIKey, если такого ключа нет.
+func (c *DBConnection) UseAPIKey(secret string) (*APIKey, error) {
+	row := c.queryRow(`SELECT id, user_id, name, prefix, scope, created_at, last_used_at FROM api_keys
+	WHERE key_hash = ?`, hashAPIKey(secret))
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := c.clock.Now()
+	_, err = c.exec(`UPDATE api_keys SET last_used_at = ? WHERE id = ?`, now.Unix(), key.ID)
+	if err != nil {
+		return nil, err
+	}
+	key.LastUsedAt = now.Format(time.RFC3339)
+	return key, nil
+}
+
+// scanAPIKey считывает описание ключа из строки результата запроса.
+func scanAPIKey(row interface{ Scan(...any) error }) (*APIKey, error) {
+	var key APIKey
+	var createdAt int64
+	var lastUsedAt sql.NullInt64
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Scope, &createdAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.CreatedAt = time.Unix(createdAt, 0).Format(time.RFC3339)
+	if lastUsedAt.Valid {
+		key.LastUsedAt = time.Unix(lastUsedAt.Int64, 0).Format(time.RFC3339)
+	}
+	return &key, nil
+}
+
+// hashAPIKey возвращает SHA-256 хэш ключа в шестнадцатеричном виде.
+// Ключи генерируются случайно и достаточно длинные, поэтому соль не нужна.
+func hashAPIKey(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
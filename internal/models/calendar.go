@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go_final_project/internal/utils"
+)
+
+// MaxCalendarDays - наибольшая длина диапазона календаря в днях.
+const MaxCalendarDays = 366
+
+// Occurrence - повторение задачи в календаре: задача с датой повторения.
+type Occurrence struct {
+	Task
+	// Virtual - повторение вычислено по правилу повторения и в базе данных не хранится;
+	// у хранимого повторения дата совпадает с датой задачи.
+	Virtual bool
+}
+
+// MarshalJSON выводит повторение как задачу с дополнительным полем virtual.
+func (o Occurrence) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		taskJSON
+		Virtual bool `json:"virtual"`
+	}{o.toJSON(), o.Virtual})
+}
+
+// Calendar - повторения активных задач пользователя с датами от From до To включительно.
+type Calendar struct {
+	From        string       `json:"from"`
+	To          string       `json:"to"`
+	Occurrences []Occurrence `json:"occurrences"`
+	// Truncated - в диапазоне есть повторения сверх MaxExpanded, в ответ попали только самые ранние.
+	Truncated bool `json:"truncated"`
+}
+
+// NewCalendar проверяет диапазон календаря и создаёт календарь без повторений.
+//
+// Параметры:
+// from, to - границы диапазона в формате "20060102" или "02.01.2006"; to не раньше from,
+// диапазон не длиннее MaxCalendarDays дней.
+//
+// Возвращает:
+// Календарь с границами в формате "20060102" или ошибку с именем неверного параметра.
+func NewCalendar(from, to string) (Calendar, error) {
+	var calendar Calendar
+	bounds := []struct {
+		name  string
+		value string
+		date  *string
+	}{{"from", from, &calendar.From}, {"to", to, &calendar.To}}
+	for _, bound := range bounds {
+		if bound.value == "" {
+			return calendar, utils.NewError(utils.CodeInvalidRequest, bound.name, "не указан параметр %s", bound.name)
+		}
+		date, err := parseFilterDate(bound.value)
+		if err != nil {
+			return calendar, utils.NewError(utils.CodeInvalidDate, bound.name, "неверный формат %s: %s", bound.name, bound.value).
+				WithDetail("format", "20060102")
+		}
+		*bound.date = date
+	}
+	start, _ := time.Parse("20060102", calendar.From)
+	end, _ := time.Parse("20060102", calendar.To)
+	if end.Before(start) {
+		return calendar, utils.NewError(utils.CodeInvalidRequest, "to", "to не может быть раньше from")
+	}
+	if end.Sub(start) >= MaxCalendarDays*24*time.Hour {
+		return calendar, utils.NewError(utils.CodeInvalidRequest, "to", "диапазон календаря не может быть длиннее %d дней", MaxCalendarDays)
+	}
+	calendar.Occurrences = []Occurrence{}
+	return calendar, nil
+}
+
+// Fill разворачивает задачи в повторения с датами в диапазоне календаря функцией expandAll: повторения упорядочены
+// по дате, времени и идентификатору задачи, если их больше MaxExpanded, остаются самые ранние и устанавливается Truncated.
+//
+// Параметры:
+// tasks - активные задачи, которые могут попасть в диапазон, с загруженными датами-исключениями (см. TaskStore.Between).
+func (c *Calendar) Fill(tasks []Task) {
+	occurrences, truncated := expandAll(tasks, c.From, c.To, MaxExpanded)
+	c.Truncated = truncated
+	stored := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		stored[task.ID] = task.Date
+	}
+	for _, occurrence := range occurrences {
+		c.Occurrences = append(c.Occurrences, Occurrence{Task: occurrence, Virtual: occurrence.Date != stored[occurrence.ID]})
+	}
+}
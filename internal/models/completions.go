@@ -0,0 +1,165 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"go_final_project/internal/utils"
+)
+
+// Completion - запись журнала выполнения задачи.
+type Completion struct {
+	ID int64 `json:"id"`
+	// Date - дата выполненного повторения в формате "20060102".
+	Date string `json:"date"`
+	// CompletedAt - время отметки о выполнении в формате RFC 3339 в часовом поясе задачи.
+	CompletedAt string `json:"completed_at"`
+	Note        string `json:"note,omitempty"`
+}
+
+// CompletionStats - счётчики, вычисленные по журналу выполнения задачи.
+type CompletionStats struct {
+	// Total - число записей в журнале.
+	Total int `json:"total"`
+	// OnTime - число повторений, отмеченных не позже своей даты.
+	OnTime int `json:"on_time"`
+	// Adherence - доля повторений по правилу задачи с первой записи журнала, выполненных вовремя, в процентах.
+	Adherence int `json:"adherence"`
+	// CurrentStreak - число последних подряд повторений, выполненных вовремя.
+	CurrentStreak int `json:"current_streak"`
+	// LongestStreak - наибольшее число повторений подряд, выполненных вовремя.
+	LongestStreak int `json:"longest_streak"`
+}
+
+// maxExpectedOccurrences ограничивает число дат, которые Stats перебирает по правилу повторения задачи.
+const maxExpectedOccurrences = 3660
+
+// Stats вычисляет счётчики по журналу выполнения, упорядоченному от новых записей к старым,
+// как его возвращает History. Повторение считается выполненным вовремя, если отметка сделана
+// не позже даты повторения по времени часового пояса задачи.
+// Доля выполненных вовремя считается от числа повторений, которые задача должна была пройти с даты первой записи
+// журнала по сегодняшний день, поэтому пропущенные и не отмеченные повторения её снижают.
+//
+// Параметры:
+// task - задача с правилом повторения и датами-исключениями.
+// completions - журнал выполнения задачи.
+// now - текущее время.
+//
+// Возвращает:
+// Счётчики выполнения.
+func Stats(task Task, completions []Completion, now time.Time) CompletionStats {
+	stats := CompletionStats{Total: len(completions)}
+	streak := 0
+	for i := len(completions) - 1; i >= 0; i-- {
+		if !completions[i].onTime() {
+			streak = 0
+			continue
+		}
+		stats.OnTime++
+		streak++
+		if streak > stats.LongestStreak {
+			stats.LongestStreak = streak
+		}
+	}
+	stats.CurrentStreak = streak
+	if stats.Total > 0 {
+		stats.Adherence = min(stats.OnTime*100/task.expectedOccurrences(completions, now), 100)
+	}
+	return stats
+}
+
+// expectedOccurrences возвращает число повторений задачи с даты первой записи журнала completions
+// по сегодняшний день: даты по правилу повторения, кроме дат-исключений, с переносом по политике Roll,
+// вместе с датами всех записей журнала, в том числе отмеченных заранее или до изменения правила.
+func (t Task) expectedOccurrences(completions []Completion, now time.Time) int {
+	const dateFormat = "20060102"
+	dates := make(map[string]bool, len(completions))
+	first := completions[0].Date
+	for _, completion := range completions {
+		dates[completion.Date] = true
+		first = min(first, completion.Date)
+	}
+	today := t.local(now).Format(dateFormat)
+	date, repeat := first, t.Repeat
+	for i := 0; repeat != "" && date <= today && i < maxExpectedOccurrences; i++ {
+		if t.RepeatUntil != "" && date > t.RepeatUntil {
+			break
+		}
+		if !t.Exceptions[date] {
+			if rolled, err := utils.RollDate(date, t.Roll, first); err == nil {
+				dates[rolled] = true
+			}
+		}
+		day, err := time.Parse(dateFormat, date)
+		if err != nil {
+			break
+		}
+		next, rule, err := utils.AdvanceRepeat(day, date, repeat)
+		if err == nil && next <= date {
+			// правило "d 1" для даты, совпадающей с текущей, возвращает её же
+			next, rule, err = utils.AdvanceRepeat(day.AddDate(0, 0, 1), date, repeat)
+		}
+		if err != nil {
+			break
+		}
+		date, repeat = next, rule
+	}
+	return len(dates)
+}
+
+// onTime проверяет, что повторение отмечено не позже своей даты.
+func (c Completion) onTime() bool {
+	completedAt, err := time.Parse(time.RFC3339, c.CompletedAt)
+	if err != nil {
+		return false
+	}
+	return completedAt.Format("20060102") <= c.Date
+}
+
+// History возвращает журнал выполнения задачи пользователя от новых записей к старым.
+//
+// Параметры:
+// - userID: Идентификатор владельца задачи.
+// - id: Идентификатор задачи.
+//
+// Возвращает:
+// - Журнал выполнения и ошибку; ErrNotFound, если задачи у пользователя нет.
+func (c *DBConnection) History(userID int64, id int) ([]Completion, error) {
+	var task Task
+	err := c.queryRow(`SELECT timezone FROM scheduler WHERE id = ? AND user_id = ?`, id, userID).Scan(&task.Timezone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.query(`SELECT id, occurrence_date, completed_at, note FROM task_completions
+	WHERE task_id = ? ORDER BY completed_at DESC, id DESC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	completions := []Completion{}
+	for rows.Next() {
+		var completion Completion
+		var completedAt int64
+		err := rows.Scan(&completion.ID, &completion.Date, &completedAt, &completion.Note)
+		if err != nil {
+			return nil, err
+		}
+		completion.CompletedAt = time.Unix(completedAt, 0).In(task.Location()).Format(time.RFC3339)
+		completions = append(completions, completion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return completions, nil
+}
+
+// recordCompletion добавляет запись в журнал выполнения задачи в рамках транзакции tx.
+func (c *DBConnection) recordCompletion(tx *sql.Tx, task Task, userID int64, note string, completedAt time.Time) error {
+	_, err := tx.Exec(c.dialect.Rebind(`INSERT INTO task_completions (task_id, user_id, occurrence_date, completed_at, note)
+	VALUES (?, ?, ?, ?, ?)`), task.ID, userID, task.Date, completedAt.Unix(), note)
+	return err
+}
@@ -3,9 +3,6 @@ package models
 import (
 	"database/sql"
 	"errors"
-	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -19,74 +16,168 @@ import (
 // получения всех задач, получения определенной задачи по ID, получения задач, содержащих определенное ключевое слово
 // в заголовке или комментарии, и получения задач, запланированных на определенную дату.
 type DBConnection struct {
-	db     *sql.DB
-	logger *zap.SugaredLogger
+	db      *sql.DB
+	dialect Dialect
+	clock   utils.Clock
+	logger  *zap.SugaredLogger
 }
 
-// NewConnection создает новый экземпляр DBConnection с указанным подключением к базе данных и логгером.
+// NewConnection создает новый экземпляр DBConnection с указанным подключением к базе данных SQLite и логгером.
 //
 // Параметры:
 // db - Указатель на объект sql.DB, представляющий подключение к базе данных.
+// clock - Часы, по которым вычисляются даты задач и отметки времени.
 // logger - Указатель на объект zap.SugaredLogger для ведения журнала.
 //
 // Возвращает:
 // Указатель на объект DBConnection, который инкапсулирует подключение к базе данных и логгер.
-func NewConnection(db *sql.DB, logger *zap.SugaredLogger) *DBConnection {
+func NewConnection(db *sql.DB, clock utils.Clock, logger *zap.SugaredLogger) *DBConnection {
+	return NewDialectConnection(db, SQLite, clock, logger)
+}
+
+// NewDialectConnection создает новый экземпляр DBConnection для базы данных с указанным SQL-диалектом.
+//
+// Параметры:
+// db - Указатель на объект sql.DB, представляющий подключение к базе данных.
+// dialect - SQL-диалект базы данных (SQLite или Postgres).
+// clock - Часы, по которым вычисляются даты задач и отметки времени.
+// logger - Указатель на объект zap.SugaredLogger для ведения журнала.
+//
+// Возвращает:
+// Указатель на объект DBConnection.
+func NewDialectConnection(db *sql.DB, dialect Dialect, clock utils.Clock, logger *zap.SugaredLogger) *DBConnection {
 	return &DBConnection{
-		db:     db,
-		logger: logger,
+		db:      db,
+		dialect: dialect,
+		clock:   clock,
+		logger:  logger,
 	}
 }
 
-// InitDB инициализирует базу данных путем создания таблицы "scheduler" и индекса на столбце "date".
-// Таблица "scheduler" имеет следующие столбцы:
-// - id: первичный ключ целого числа с автоинкрементом
-// - date: поле символьного типа длиной 8 символов, не может быть NULL, имеет значение по умолчанию пустая строка
-// - title: поле переменной длины строки с максимальной длиной 128 символов, не может быть NULL, имеет значение по умолчанию пустая строка
-// - comment: текстовое поле для хранения комментариев
-// - repeat: поле переменной длины строки с максимальной длиной 128 символов, не может быть NULL, имеет значение по умолчанию пустая строка
-//
-// Если во время выполнения SQL-запросов возникает ошибка, она будет выведена в консоль.
-func (c *DBConnection) InitDB() error {
-	const (
-		CreateTableQuery = `CREATE TABLE scheduler (
-		id      INTEGER PRIMARY KEY AUTOINCREMENT,
-		date    CHAR(8) NOT NULL DEFAULT "",
-		title   VARCHAR(128) NOT NULL DEFAULT "",
-		comment TEXT,
-		repeat VARCHAR(128) NOT NULL DEFAULT "" 
-		);`
-	)
-	if _, err := c.db.Exec(CreateTableQuery); err != nil {
-		return err
+// exec выполняет запрос с плейсхолдерами "?", переписанными под диалект подключения.
+func (c *DBConnection) exec(query string, args ...any) (sql.Result, error) {
+	return c.db.Exec(c.dialect.Rebind(query), args...)
+}
+
+// query выполняет запрос, возвращающий строки, с плейсхолдерами диалекта подключения.
+func (c *DBConnection) query(query string, args ...any) (*sql.Rows, error) {
+	return c.db.Query(c.dialect.Rebind(query), args...)
+}
+
+// queryRow выполняет запрос, возвращающий одну строку, с плейсхолдерами диалекта подключения.
+func (c *DBConnection) queryRow(query string, args ...any) *sql.Row {
+	return c.db.QueryRow(c.dialect.Rebind(query), args...)
+}
+
+// taskColumns - столбцы таблицы scheduler в порядке, в котором их читает scanTask.
+const taskColumns = `id, date, title, comment, repeat, version, completed_at, repeat_until, repeat_count, roll,
+due_time, timezone, priority, tags, schedule_date`
+
+// clause возвращает условие SQL для отбора задач со статусом s, которое дописывается к WHERE.
+func (s TaskStatus) clause() string {
+	switch s {
+	case StatusDone:
+		return ` AND completed_at <> 0`
+	case StatusAll:
+		return ``
+	default:
+		return ` AND completed_at = 0`
 	}
+}
 
-	if _, err := c.db.Exec(`CREATE INDEX taks_date ON scheduler (date);`); err != nil {
-		return err
+// rowScanner - общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask читает задачу из строки, выбранной по столбцам taskColumns.
+func scanTask(row rowScanner) (Task, error) {
+	return scanRankedTask(row, false)
+}
+
+// scanRankedTask читает задачу из строки, выбранной по столбцам taskColumns; если ranked, за ними следует столбец rank.
+func scanRankedTask(row rowScanner, ranked bool) (Task, error) {
+	task := Task{}
+	var completedAt int64
+	dest := []any{&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat, &task.Version, &completedAt,
+		&task.RepeatUntil, &task.RepeatCount, &task.Roll, &task.Time, &task.Timezone, &task.Priority, &task.Tags,
+		&task.ScheduleDate}
+	if ranked {
+		dest = append(dest, &task.rank)
+	}
+	err := row.Scan(dest...)
+	if err != nil {
+		return task, err
 	}
-	return nil
+	if completedAt != 0 {
+		task.CompletedAt = time.Unix(completedAt, 0).In(task.Location()).Format(time.RFC3339)
+	}
+	return task, nil
+}
+
+// scanTasks читает все задачи из rows и закрывает их; ranked - после столбцов задачи выбран столбец rank.
+func scanTasks(rows *sql.Rows, ranked bool) ([]Task, error) {
+	defer rows.Close()
+	tasks := []Task{}
+	for rows.Next() {
+		task, err := scanRankedTask(rows, ranked)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// listTasks возвращает страницу задач пользователя, отобранных частью запроса filter,
+// в порядке и после курсора выборки query. Условие filter.where дописывается к WHERE после отбора по пользователю и статусу.
+// Если filter присоединяет индекс полнотекстового поиска, задачи можно упорядочить по его столбцу rank.
+func (c *DBConnection) listTasks(userID int64, filter taskSQL, query TaskQuery) (TaskPage, error) {
+	query.ranked = filter.join != ""
+	args := append(append(append([]any{}, filter.joinArgs...), userID), filter.args...)
+	from := `FROM scheduler` + filter.join + ` WHERE user_id = ?` + query.Status.clause() + filter.where
+	var total int
+	err := c.queryRow(`SELECT COUNT(*) `+from, args...).Scan(&total)
+	if err != nil {
+		return TaskPage{}, err
+	}
+	columns := taskColumns
+	if query.ranked {
+		columns += `, rank`
+	}
+	after, afterArgs := query.afterClause()
+	args = append(append(args, afterArgs...), query.Limit+1)
+	rows, err := c.query(`SELECT `+columns+` `+from+after+query.orderBy()+` LIMIT ?`, args...)
+	if err != nil {
+		return TaskPage{}, err
+	}
+	tasks, err := scanTasks(rows, query.ranked)
+	if err != nil {
+		return TaskPage{}, err
+	}
+	return query.page(tasks, total), nil
 }
 
-// CheckID проверяет, существует ли указанный идентификатор в базе данных.
-// Он извлекает максимальный идентификатор из таблицы "scheduler" и сравнивает его с указанным идентификатором.
-// Если указанный идентификатор больше максимального идентификатора, возвращается ошибка, указывающая, что идентификатор больше числа строк в базе данных.
+// CheckID проверяет, существует ли задача с указанным идентификатором у указанного пользователя.
 //
 // Параметры:
+// - userID: Идентификатор владельца задачи.
 // - id: Проверяемый идентификатор. Это целое число.
 //
 // Возвращает:
-// - Ошибку, если указанный идентификатор больше максимального идентификатора в базе данных.
-// - nil, если указанный идентификатор существует в базе данных.
-func (c *DBConnection) CheckID(id int) error {
-	var maxID int
-	row := c.db.QueryRow(`SELECT MAX(id) FROM scheduler`)
-	row.Scan(&maxID)
-	if err := row.Err(); err != nil {
+// - Ошибку, если задачи с указанным идентификатором у пользователя нет.
+// - nil, если задача существует.
+func (c *DBConnection) CheckID(userID int64, id int) error {
+	var count int
+	err := c.queryRow(`SELECT COUNT(*) FROM scheduler WHERE id = ? AND user_id = ?`, id, userID).Scan(&count)
+	if err != nil {
 		return err
 	}
-	if id > maxID {
-		err := errors.New("given id is more than number of rows in DB")
-		return err
+	if count == 0 {
+		return ErrNotFound
 	}
 	return nil
 }
@@ -94,80 +185,168 @@ func (c *DBConnection) CheckID(id int) error {
 // Delete удаляет задачу из базы данных на основе указанного идентификатора.
 //
 // Параметры:
+// - userID: Идентификатор владельца задачи.
 // - id: Уникальный идентификатор удаляемой задачи.
+// Вместе с задачей удаляются её журнал выполнения и даты-исключения.
 //
 // Возвращает:
 // - Ошибку, если во время удаления произошла ошибка. Если удаление выполнено успешно, возвращается nil
-func (c *DBConnection) Delete(id int) error {
-	res, err := c.db.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+func (c *DBConnection) Delete(userID int64, id int) error {
+	err := c.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(c.dialect.Rebind(`DELETE FROM task_completions WHERE task_id = ? AND user_id = ?`), id, userID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(c.dialect.Rebind(`DELETE FROM task_exceptions WHERE task_id = ? AND user_id = ?`), id, userID)
+		if err != nil {
+			return err
+		}
+		res, err := tx.Exec(c.dialect.Rebind(`DELETE FROM scheduler WHERE id = ? AND user_id = ?`), id, userID)
+		if err != nil {
+			return err
+		}
+		num, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if num != 1 {
+			return ErrNotFound
+		}
+		return c.unindexTasks(tx, `rowid = ?`, id)
+	})
 	if err != nil {
 		c.logger.Errorw("error deleting task", "error", err)
 		return err
 	}
-	num, err := res.RowsAffected()
-	if err != nil {
-		c.logger.Errorw("error getting rows affected", "error", err)
-		return err
-	}
-	if num != 1 {
-		err = errors.New("no such id")
-		c.logger.Error(err)
-		return err
-	}
 	c.logger.Infof("Task with ID: %d was deleted", id)
 	return nil
 }
 
-// Insert вставляет новую задачу в базу данных.
+// Insert вставляет новую задачу пользователя в базу данных.
 //
 // Параметры:
+// - userID: Идентификатор владельца задачи.
 // - task: Структура, содержащая данные новой задачи.
 //
 // Возвращает:
 // - Идентификатор вставленной задачи и ошибку, если во время вставки произошла ошибка.
 // - Если вставка выполнена успешно, возвращается идентификатор вставленной задачи и nil.
-func (c *DBConnection) Insert(task *Task) (int, error) {
-	res, err := c.db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, ?, ?, ?)`,
-		task.Date, task.Title, task.Comment, task.Repeat)
+func (c *DBConnection) Insert(userID int64, task *Task) (int, error) {
+	var id int64
+	err := c.inTx(func(tx *sql.Tx) error {
+		err := tx.QueryRow(c.dialect.Rebind(`INSERT INTO scheduler (date, title, comment, repeat, repeat_until, repeat_count, roll,
+		due_time, timezone, priority, tags, schedule_date, user_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`),
+			task.Date, task.Title, task.Comment, task.Repeat, task.RepeatUntil, task.RepeatCount, task.Roll, task.Time, task.Timezone,
+			task.Priority, task.Tags, task.ScheduleDate, userID).Scan(&id)
+		if err != nil {
+			return err
+		}
+		return c.indexTask(tx, id, task)
+	})
 	if err != nil {
 		c.logger.Errorw("Error inserting task", "error", err)
-		return 0, nil
-	}
-	id, err := res.LastInsertId()
-	if err != nil {
-		c.logger.Errorw("Error getting last insert id", "error", err)
 		return 0, err
 	}
 	c.logger.Infof("Task inserted with ID: %d", id)
 	return int(id), nil
 }
 
-// Update обновляет данные существующей задачи в базе данных.
+// scheduleDateUpdate - присваивание UPDATE, которое сохраняет schedule_date, только если новая дата задачи (параметр)
+// совпадает с прежней: дата, изменённая пользователем, становится новой датой расписания.
+const scheduleDateUpdate = `schedule_date = CASE WHEN date = ? THEN schedule_date ELSE '' END`
+
+// Update обновляет данные существующей задачи пользователя в базе данных.
 //
 // Параметры:
+// - userID: Идентификатор владельца задачи.
 // - task: Структура, содержащая обновленные данные задачи.
 //
 // Возвращает:
 // - Ошибку, если во время обновления произошла ошибка. Если обновление выполнено успешно, возвращается nil.
-func (c *DBConnection) Update(task *Task) error {
-	res, err := c.db.Exec(`UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ? WHERE id = ?`,
-		task.Date, task.Title, task.Comment, task.Repeat, task.ID)
+// - ErrConflict, если в задаче указана версия и она не совпадает с сохранённой.
+func (c *DBConnection) Update(userID int64, task *Task) error {
+	if task.Version != 0 {
+		return c.updateVersion(userID, task)
+	}
+	err := c.inTx(func(tx *sql.Tx) error {
+		res, err := tx.Exec(c.dialect.Rebind(`UPDATE scheduler SET `+scheduleDateUpdate+`, date = ?, title = ?, comment = ?,
+		repeat = ?, repeat_until = ?, repeat_count = ?, roll = ?, due_time = ?, timezone = ?, priority = ?, tags = ?,
+		version = version + 1 WHERE id = ? AND user_id = ?`),
+			task.Date, task.Date, task.Title, task.Comment, task.Repeat, task.RepeatUntil, task.RepeatCount, task.Roll, task.Time,
+			task.Timezone, task.Priority, task.Tags, task.ID, userID)
+		if err != nil {
+			return err
+		}
+		num, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if num != 1 {
+			return ErrNotFound
+		}
+		return c.indexTask(tx, task.ID, task)
+	})
 	if err != nil {
 		c.logger.Errorw("error updating task", "error", err)
 		return err
 	}
-	num, err := res.RowsAffected()
-	if err != nil {
-		c.logger.Errorw("error getting rows affected", "error", err)
-		return err
+	c.logger.Infof("Task `%s` updated", task.Title)
+	return nil
+}
+
+// updateVersion обновляет задачу пользователя, только если её версия в базе данных совпадает с task.Version.
+func (c *DBConnection) updateVersion(userID int64, task *Task) error {
+	return c.inTx(func(tx *sql.Tx) error {
+		var version int
+		err := tx.QueryRow(c.dialect.Rebind(`SELECT version FROM scheduler WHERE id = ? AND user_id = ?`),
+			task.ID, userID).Scan(&version)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if version != task.Version {
+			return ErrConflict
+		}
+		_, err = tx.Exec(c.dialect.Rebind(`UPDATE scheduler SET `+scheduleDateUpdate+`, date = ?, title = ?, comment = ?,
+		repeat = ?, repeat_until = ?, repeat_count = ?, roll = ?, due_time = ?, timezone = ?, priority = ?, tags = ?,
+		version = version + 1 WHERE id = ? AND user_id = ? AND version = ?`),
+			task.Date, task.Date, task.Title, task.Comment, task.Repeat, task.RepeatUntil, task.RepeatCount, task.Roll, task.Time,
+			task.Timezone, task.Priority, task.Tags, task.ID, userID, task.Version)
+		if err != nil {
+			return err
+		}
+		err = c.indexTask(tx, task.ID, task)
+		if err != nil {
+			return err
+		}
+		c.logger.Infof("Task `%s` updated", task.Title)
+		return nil
+	})
+}
+
+// indexTask записывает название и комментарий задачи с идентификатором id в индекс полнотекстового поиска
+// scheduler_fts в рамках транзакции tx. В диалектах без FTS5 ничего не делает.
+func (c *DBConnection) indexTask(tx *sql.Tx, id any, task *Task) error {
+	if !c.dialect.fts {
+		return nil
 	}
-	if num != 1 {
-		err = errors.New("no such id")
-		c.logger.Error(err)
+	if err := c.unindexTasks(tx, `rowid = ?`, id); err != nil {
 		return err
 	}
-	c.logger.Infof("Task `%s` updated", task.Title)
-	return nil
+	_, err := tx.Exec(`INSERT INTO scheduler_fts (rowid, title, comment) VALUES (?, ?, ?)`, id, task.Title, task.Comment)
+	return err
+}
+
+// unindexTasks удаляет из индекса полнотекстового поиска записи, отобранные условием where по столбцу rowid
+// (идентификатору задачи), в рамках транзакции tx. В диалектах без FTS5 ничего не делает.
+func (c *DBConnection) unindexTasks(tx *sql.Tx, where string, args ...any) error {
+	if !c.dialect.fts {
+		return nil
+	}
+	_, err := tx.Exec(`DELETE FROM scheduler_fts WHERE `+where, args...)
+	return err
 }
 
 // UpdateDate обновляет дату существующей задачи в базе данных.
@@ -178,7 +357,7 @@ func (c *DBConnection) Update(task *Task) error {
 // Возвращает:
 // - Ошибку, если во время обновления произошла ошибка. Если обновление выполнено успешно, возвращается nil.
 func (c *DBConnection) UpdateDate(task *Task) error {
-	_, err := c.db.Exec(`UPDATE scheduler SET date = ? WHERE id = ?`,
+	_, err := c.exec(`UPDATE scheduler SET date = ?, version = version + 1 WHERE id = ?`,
 		task.Date, task.ID)
 	if err != nil {
 		return err
@@ -187,246 +366,222 @@ func (c *DBConnection) UpdateDate(task *Task) error {
 	return nil
 }
 
-// GetAll извлекает все задачи из базы данных с ограничением на количество возвращаемых записей.
+// GetAll извлекает страницу задач пользователя из базы данных.
 //
 // Параметры:
-// - limit: Максимальное количество возвращаемых записей.
+// - userID: Идентификатор владельца задач.
+// - query: Статус задач, размер страницы, сортировка и курсор.
 //
 // Возвращает:
-// - Словарь, содержащий массив задач и ошибку, если во время извлечения произошла ошибка.
-// - Если извлечение выполнено успешно, возвращается словарь с массивом задач и nil.
-func (c *DBConnection) GetAll(limit int) (map[string][]Task, error) {
-	tasks := make(map[string][]Task)
-	rows, err := c.db.Query(`SELECT id, date, title, comment, repeat FROM scheduler
-	ORDER BY date LIMIT :limit`,
-		sql.Named("limit", limit))
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	for rows.Next() {
-		task := Task{}
-		err := rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
-		if err != nil {
-			return nil, err
-		}
-		tasks["tasks"] = append(tasks["tasks"], task)
-	}
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-	if tasks["tasks"] == nil {
-		tasks["tasks"] = []Task{}
-	}
-	return tasks, nil
+// - Страницу задач с курсором следующей страницы и общим числом задач и ошибку, если во время извлечения произошла ошибка.
+func (c *DBConnection) GetAll(userID int64, query TaskQuery) (TaskPage, error) {
+	return c.listTasks(userID, taskSQL{}, query)
 }
 
-// GetTask извлекает конкретную задачу из базы данных на основе указанного идентификатора.
+// GetTask извлекает конкретную задачу пользователя из базы данных на основе указанного идентификатора.
 //
 // Параметры:
+// - userID: Идентификатор владельца задачи.
 // - id: Уникальный идентификатор извлекаемой задачи.
 //
 // Возвращает:
 // - Указатель на структуру, содержащую данные извлеченной задачи и ошибку, если во время извлечения произошла ошибка.
 // - Если извлечение выполнено успешно, возвращается указатель на структуру с данными задачи и nil.
-func (c *DBConnection) GetTask(id int) (*Task, error) {
+func (c *DBConnection) GetTask(userID int64, id int) (*Task, error) {
 	// Получаем задачу по идентификатору
-	task := Task{}
-	rows, err := c.db.Query(`SELECT id, date, title, comment, repeat FROM scheduler WHERE id = ?`, id)
-	if err != nil {
-		return &task, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		err = rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
-		if err != nil {
-			return nil, err
-		}
+	task, err := scanTask(c.queryRow(`SELECT `+taskColumns+` FROM scheduler WHERE id = ? AND user_id = ?`, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, utils.Errorf("задача %d: %w", id, ErrNotFound)
 	}
-	if err = rows.Err(); err != nil {
+	if err != nil {
 		return nil, err
 	}
-	if task.Date == "" && task.Title == "" && task.Repeat == "" && task.Comment == "" {
-		return nil, fmt.Errorf("no rows for id %d", id)
-	}
 	return &task, nil
 }
 
-// GetByWord извлекает все задачи, содержащие указанное ключевое слово в заголовке или комментарии,
-// с ограничением на количество возвращаемых записей.
+// Search ищет задачи пользователя userID по поисковому запросу key и возвращает страницу результатов.
+// Запрос разбирается функцией ParseFilter в условия, которые подставляются в SQL-запрос как параметры.
 //
 // Параметры:
-// - key: Ключевое слово для поиска.
-// - limit: Максимальное количество возвращаемых записей.
+// - userID: Идентификатор владельца задач.
+// - key: Поисковый запрос, например `tag:work due<01.11.2026 созвон`.
+// - query: Статус задач, размер страницы, сортировка и курсор.
 //
 // Возвращает:
-// - Словарь, содержащий массив задач и ошибку, если во время извлечения произошла ошибка.
-// - Если извлечение выполнено успешно, возвращается словарь с массивом задач и nil.
-func (c *DBConnection) GetByWord(key string, limit int) (map[string][]Task, error) {
-	tasks := make(map[string][]Task)
-	rows, err := c.db.Query(`SELECT id, date, title, comment, repeat FROM scheduler
-	WHERE title LIKE :search OR comment LIKE :search ORDER BY date LIMIT :limit`,
-		sql.Named("search", "%"+key+"%"),
-		sql.Named("limit", limit))
+// - Страницу задач и ошибку; ошибку с кодом invalid_request, если запрос неверен.
+func (c *DBConnection) Search(userID int64, key string, query TaskQuery) (TaskPage, error) {
+	filter, err := ParseFilter(key)
 	if err != nil {
-		return nil, err
+		return TaskPage{}, err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		task := Task{}
-		err := rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
-		if err != nil {
-			return nil, err
-		}
-		tasks["tasks"] = append(tasks["tasks"], task)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	page, err := c.listTasks(userID, filter.sql(c.dialect, filterToday(c.clock.Now())), query)
+	if err != nil {
+		c.logger.Error(err)
+		return TaskPage{}, err
 	}
-	if tasks["tasks"] == nil {
-		tasks["tasks"] = []Task{}
+	for i := range page.Tasks {
+		page.Tasks[i].Snippet = filter.snippet(page.Tasks[i])
 	}
-	return tasks, nil
+	return page, nil
 }
 
-// GetByDate извлекает все задачи, запланированные на указанную дату, с ограничением на количество возвращаемых записей.
+// Done помечает задачу как выполненную и выполняет дополнительные действия.
+// Если задача повторяется, она вычисляет дату следующего повторения и обновляет ее в хранилище.
+// Если задача не повторяется или её повторения закончились, она получает отметку времени выполнения completed_at
+// и остаётся в хранилище.
+// В обоих случаях выполненное повторение записывается в журнал выполнения task_completions.
+// Чтение и изменение задачи выполняются в одной транзакции. Запросы выполняются по очереди, поэтому второй
+// из двух одновременных запросов прочитает уже перенесённую задачу: чтобы он не перенёс её ещё раз,
+// клиент передаёт версию или дату повторения, которые он видел.
 //
 // Параметры:
-// - date: Дата для поиска.
-// - limit: Максимальное количество возвращаемых записей.
+// userID - идентификатор владельца задачи.
+// id - идентификатор задачи, которую необходимо пометить как выполненную.
+// version - ожидаемая версия задачи; 0 - не проверять версию.
+// date - ожидаемая дата выполняемого повторения в формате "20060102"; пустая строка - не проверять дату.
+// note - необязательная заметка к записи журнала выполнения.
 //
 // Возвращает:
-// - Словарь, содержащий массив задач и ошибку, если во время извлечения произошла ошибка.
-// - Если извлечение выполнено успешно, возвращается словарь с массивом задач и nil.
-func (c *DBConnection) GetByDate(date string, limit int) (map[string][]Task, error) {
-	tasks := make(map[string][]Task)
-	dateTime, err := time.Parse("02.01.2006", date)
+// error - ErrNotFound, если задачи нет; ErrConflict, если задача была изменена другим запросом, уже перенесена
+// с даты date или уже выполнена; ошибку, обёрнутую в ErrInvalidRepeat, если правило повторения неверно;
+// nil, если операция выполнена успешно.
+func (c *DBConnection) Done(userID int64, id int, version int, date, note string) error {
+	err := c.inTx(func(tx *sql.Tx) error {
+		task, err := scanTask(tx.QueryRow(c.dialect.Rebind(`SELECT `+taskColumns+` FROM scheduler
+		WHERE id = ? AND user_id = ?`), id, userID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := task.checkDone(version, date); err != nil {
+			return err
+		}
+		task.Exceptions, err = c.loadExceptions(tx, id)
+		if err != nil {
+			return err
+		}
+		now := c.clock.Now()
+		next, completed, err := task.afterDone(now)
+		if err != nil {
+			return err
+		}
+		err = c.recordCompletion(tx, task, userID, note, now)
+		if err != nil {
+			return err
+		}
+		err = c.advance(tx, task, next, completed, now)
+		if err != nil {
+			return err
+		}
+		if completed {
+			c.logger.Infof("Task `%s` done and completed", task.Title)
+		} else {
+			c.logger.Infof("Task `%s` done", task.Title)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		c.logger.Error(err)
 	}
-	dateFormat := dateTime.Format("20060102")
-	rows, err := c.db.Query(`SELECT id, date, title, comment, repeat FROM scheduler
-		WHERE date = :date LIMIT :limit`,
-		sql.Named("date", dateFormat),
-		sql.Named("limit", limit))
-	if err != nil {
-		return nil, err
+	return err
+}
+
+// advance сохраняет в рамках транзакции tx состояние задачи после выполнения или пропуска повторения:
+// завершённая задача получает отметку времени выполнения now, иначе сохраняются дата, правило и число повторений next.
+// Изменение применяется, только если версия задачи не изменилась с момента чтения, иначе возвращается ErrConflict.
+func (c *DBConnection) advance(tx *sql.Tx, task, next Task, completed bool, now time.Time) error {
+	var res sql.Result
+	var err error
+	if completed {
+		res, err = tx.Exec(c.dialect.Rebind(`UPDATE scheduler SET completed_at = ?, version = version + 1
+		WHERE id = ? AND version = ?`), now.Unix(), task.ID, task.Version)
+	} else {
+		res, err = tx.Exec(c.dialect.Rebind(`UPDATE scheduler SET date = ?, schedule_date = ?, repeat = ?, repeat_count = ?,
+		version = version + 1 WHERE id = ? AND version = ?`), next.Date, next.ScheduleDate, next.Repeat, next.RepeatCount, task.ID,
+			task.Version)
 	}
-	defer rows.Close()
-	for rows.Next() {
-		task := Task{}
-		err := rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
-		if err != nil {
-			return nil, err
-		}
-		tasks["tasks"] = append(tasks["tasks"], task)
+	if err != nil {
+		return err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	num, err := res.RowsAffected()
+	if err != nil {
+		return err
 	}
-	if tasks["tasks"] == nil {
-		tasks["tasks"] = []Task{}
+	if num != 1 {
+		return ErrConflict
 	}
-	return tasks, nil
+	return nil
 }
 
-// Search ищет задачи по указанному ключу (слово или дата).
-// Сначала он пытается разобрать ключ как дату с использованием формата "02.01.2006".
-// Если это успешно, он вызывает метод GetByDate хранилища Task для получения задач по дате.
-// Если ключ не может быть разобран как дата, он вызывает метод GetByWord хранилища Task для получения задач по слову.
-// Если какой-либо из методов возвращает ошибку, он регистрирует ошибку с использованием предоставленного журнала и возвращает nil, error.
-// Если ключ "tasks" в возвращенном словаре равен nil, он инициализирует его пустым массивом Task.
-// Наконец, он возвращает словарь задач и nil.
-func (c *DBConnection) Search(key string, limit int) (map[string][]Task, error) {
-	const srchFormat = "02.01.2006"
-	_, err := time.Parse(srchFormat, key)
-	var tasks map[string][]Task
-	if err != nil {
-		tasks, err = c.GetByWord(key, limit)
+// Undone снимает с выполненной задачи пользователя отметку о выполнении, и задача снова становится активной.
+// Последняя запись журнала выполнения задачи удаляется.
+//
+// Параметры:
+// userID - идентификатор владельца задачи.
+// id - идентификатор задачи.
+//
+// Возвращает:
+// error - ErrNotFound, если задачи нет; ErrConflict, если задача не выполнена; nil, если операция выполнена успешно.
+func (c *DBConnection) Undone(userID int64, id int) error {
+	err := c.inTx(func(tx *sql.Tx) error {
+		var completedAt int64
+		err := tx.QueryRow(c.dialect.Rebind(`SELECT completed_at FROM scheduler WHERE id = ? AND user_id = ?`), id, userID).
+			Scan(&completedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
 		if err != nil {
-			c.logger.Error(err)
-			return nil, err
+			return err
 		}
-	} else {
-		tasks, err = c.GetByDate(key, limit)
+		if completedAt == 0 {
+			return utils.Errorf("%w: задача не выполнена", ErrConflict)
+		}
+		_, err = tx.Exec(c.dialect.Rebind(`UPDATE scheduler SET completed_at = 0, version = version + 1 WHERE id = ?`), id)
 		if err != nil {
-			c.logger.Error(err)
-			return nil, err
+			return err
 		}
+		_, err = tx.Exec(c.dialect.Rebind(`DELETE FROM task_completions
+		WHERE id = (SELECT MAX(id) FROM task_completions WHERE task_id = ?)`), id)
+		return err
+	})
+	if err != nil {
+		c.logger.Errorw("error reopening task", "error", err)
+		return err
 	}
-	if tasks["tasks"] == nil {
-		tasks["tasks"] = []Task{}
-	}
-	return tasks, nil
+	c.logger.Infof("Task with ID: %d was reopened", id)
+	return nil
 }
 
-// Done помечает задачу как выполненную и выполняет дополнительные действия.
-// Если задача повторяется, она вычисляет дату следующего повторения и обновляет ее в хранилище.
-// Если дата следующего повторения совпадает с текущей датой, она вычисляет новую дату повторения
-// исходя из указанного интервала повторения и обновляет ее в хранилище.
-// Если задача не повторяется, она удаляется из хранилища.
+// PurgeCompleted удаляет задачи всех пользователей, выполненные раньше момента before,
+// вместе с их журналом выполнения и датами-исключениями.
 //
 // Параметры:
-// id - идентификатор задачи, которую необходимо пометить как выполненную.
+// before - граница времени выполнения удаляемых задач.
 //
 // Возвращает:
-// error - возвращает ошибку, если она возникла во время выполнения операции, или nil, если операция выполнена успешно.
-func (c *DBConnection) Done(id int) error {
-	const dateFormat = "20060102"
-	task, err := c.GetTask(id)
-	if err != nil {
-		c.logger.Error(err)
-		return err
-	}
-	if task.Repeat != "" {
-		task.Date, err = utils.NextDate(time.Now(), task.Date, task.Repeat)
-
-		if err != nil {
-			c.logger.Error(err)
-			return err
-		}
-		if task.Date == time.Now().Format(dateFormat) {
-			date, err := time.Parse(dateFormat, task.Date)
+// Количество удалённых задач и ошибку, если удаление завершилось неудачно.
+func (c *DBConnection) PurgeCompleted(before time.Time) (int64, error) {
+	var purged int64
+	err := c.inTx(func(tx *sql.Tx) error {
+		for _, table := range []string{"task_completions", "task_exceptions"} {
+			_, err := tx.Exec(c.dialect.Rebind(`DELETE FROM `+table+` WHERE task_id IN
+			(SELECT id FROM scheduler WHERE completed_at <> 0 AND completed_at < ?)`), before.Unix())
 			if err != nil {
-				c.logger.Error(err)
-				return err
-			}
-			rptSlc := strings.Split(task.Repeat, " ")
-			subDays, err := strconv.Atoi(rptSlc[1])
-			task.Date = date.AddDate(0, 0, subDays).Format(dateFormat)
-			if err != nil {
-				c.logger.Error(err)
 				return err
 			}
 		}
-		c.UpdateDate(task)
-		c.logger.Infof("Task `%s` done", task.Title)
-		return nil
-	} else {
-		id, err := strconv.Atoi(task.ID)
+		err := c.unindexTasks(tx, `rowid IN (SELECT id FROM scheduler WHERE completed_at <> 0 AND completed_at < ?)`, before.Unix())
 		if err != nil {
-			c.logger.Error(err)
 			return err
 		}
-		c.Delete(id)
-		c.logger.Infof("Task `%s` done and deleted", task.Title)
-	}
-	return nil
-}
-
-func (s *DBConnection) DateToAdd(task *Task) error {
-	nextDate, err := task.CompleteRequest()
-	if err != nil {
-		return err
-	}
-	task.Date = nextDate
-	if task.Date != "" {
-		nextDate, err = task.CheckDate()
+		res, err := tx.Exec(c.dialect.Rebind(`DELETE FROM scheduler WHERE completed_at <> 0 AND completed_at < ?`), before.Unix())
 		if err != nil {
 			return err
 		}
-		task.Date = nextDate
-	}
-	return nil
+		purged, err = res.RowsAffected()
+		return err
+	})
+	return purged, err
 }
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect описывает различия SQL-диалектов, которые учитывает DBConnection.
+// Запросы в пакете пишутся с плейсхолдерами "?" и переносимым синтаксисом (LIMIT ?, ON CONFLICT, RETURNING),
+// а диалект переписывает плейсхолдеры и DDL под конкретную СУБД.
+type Dialect struct {
+	// Name - название диалекта.
+	Name string
+	// numbered - плейсхолдеры вида $1, $2 вместо ?.
+	numbered bool
+	// serial - тип автоинкрементного первичного ключа.
+	serial string
+	// columnExists - запрос, проверяющий наличие столбца (параметры: таблица, столбец).
+	columnExists string
+	// contains - функция, возвращающая позицию подстроки в строке или 0, если подстроки нет.
+	contains string
+	// like - оператор сравнения с шаблоном без учёта регистра.
+	like string
+	// fts - поиск по словам выполняется через таблицу полнотекстового поиска FTS5 scheduler_fts.
+	fts bool
+}
+
+var (
+	// SQLite - диалект SQLite (драйвер "sqlite").
+	SQLite = Dialect{
+		Name:         "sqlite",
+		serial:       "INTEGER PRIMARY KEY AUTOINCREMENT",
+		columnExists: `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`,
+		contains:     "instr",
+		like:         "LIKE",
+		fts:          true,
+	}
+	// Postgres - диалект PostgreSQL.
+	Postgres = Dialect{
+		Name:         "postgres",
+		numbered:     true,
+		serial:       "SERIAL PRIMARY KEY",
+		columnExists: `SELECT COUNT(*) FROM information_schema.columns WHERE table_name = ? AND column_name = ?`,
+		contains:     "strpos",
+		like:         "ILIKE",
+	}
+)
+
+// Rebind заменяет плейсхолдеры "?" в запросе на плейсхолдеры диалекта.
+// Знаки вопроса внутри строковых литералов не заменяются.
+//
+// Параметры:
+// - query: Запрос с плейсхолдерами "?".
+//
+// Возвращает:
+// - Запрос с плейсхолдерами диалекта.
+func (d Dialect) Rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	inString := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case r == '?' && !inString:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// DDL подставляет в запрос создания схемы тип автоинкрементного ключа диалекта вместо "{{serial}}".
+func (d Dialect) DDL(query string) string {
+	return strings.ReplaceAll(query, "{{serial}}", d.serial)
+}
+
+// Contains возвращает условие, истинное, если выражение expr содержит строку из плейсхолдера "?".
+// В отличие от LIKE, сравнение учитывает регистр любых букв и не придаёт особого значения символам "%" и "_".
+func (d Dialect) Contains(expr string) string {
+	return d.contains + "(" + expr + ", ?) > 0"
+}
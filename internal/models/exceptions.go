@@ -0,0 +1,146 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+
+	"go_final_project/internal/utils"
+)
+
+// Skip добавляет дату-исключение повторяющейся задаче пользователя: в эту дату повторение не выполняется.
+// Если дата совпадает с текущей датой задачи, задача сразу переносится на следующее повторение,
+// которое не попадает на исключения, без записи в журнал выполнения; число оставшихся повторений repeat_count
+// при этом не уменьшается.
+//
+// Параметры:
+// - userID: Идентификатор владельца задачи.
+// - id: Идентификатор задачи.
+// - date: Пропускаемая дата в формате "20060102"; пустая строка означает текущую дату задачи.
+//
+// Возвращает:
+// - Ошибку; ErrNotFound, если задачи нет, ErrNotRepeating, если задача не повторяется,
+// ErrConflict, если задача уже выполнена, изменена одновременно или пропускается её последнее повторение.
+func (c *DBConnection) Skip(userID int64, id int, date string) error {
+	return c.inTx(func(tx *sql.Tx) error {
+		task, err := scanTask(tx.QueryRow(c.dialect.Rebind(`SELECT `+taskColumns+` FROM scheduler
+		WHERE id = ? AND user_id = ?`), id, userID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if task.Repeat == "" {
+			return ErrNotRepeating
+		}
+		if task.CompletedAt != "" {
+			return utils.Errorf("%w: задача уже выполнена", ErrConflict)
+		}
+		if date == "" {
+			date = task.Date
+		}
+		_, err = tx.Exec(c.dialect.Rebind(`INSERT INTO task_exceptions (task_id, user_id, date) VALUES (?, ?, ?)
+		ON CONFLICT (task_id, date) DO NOTHING`), id, userID, date)
+		if err != nil {
+			return err
+		}
+		if date != task.Date {
+			return nil
+		}
+		task.Exceptions, err = c.loadExceptions(tx, id)
+		if err != nil {
+			return err
+		}
+		now := c.clock.Now()
+		next, err := task.afterSkip(now)
+		if err != nil {
+			return err
+		}
+		return c.advance(tx, task, next, false, now)
+	})
+}
+
+// Exceptions возвращает даты-исключения задачи пользователя в порядке возрастания.
+//
+// Параметры:
+// - userID: Идентификатор владельца задачи.
+// - id: Идентификатор задачи.
+//
+// Возвращает:
+// - Список дат в формате "20060102" и ошибку; ErrNotFound, если задачи у пользователя нет.
+func (c *DBConnection) Exceptions(userID int64, id int) ([]string, error) {
+	if err := c.CheckID(userID, id); err != nil {
+		return nil, err
+	}
+	rows, err := c.query(`SELECT date FROM task_exceptions WHERE task_id = ? ORDER BY date`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	dates := []string{}
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return dates, nil
+}
+
+// DeleteException удаляет дату-исключение задачи пользователя. Дата задачи при этом не меняется.
+//
+// Параметры:
+// - userID: Идентификатор владельца задачи.
+// - id: Идентификатор задачи.
+// - date: Дата-исключение в формате "20060102".
+//
+// Возвращает:
+// - Ошибку; ErrNotFound, если задачи или такой даты-исключения нет.
+func (c *DBConnection) DeleteException(userID int64, id int, date string) error {
+	res, err := c.db.Exec(c.dialect.Rebind(`DELETE FROM task_exceptions WHERE task_id = ? AND user_id = ? AND date = ?`),
+		id, userID, date)
+	if err != nil {
+		return err
+	}
+	num, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if num == 0 {
+		return utils.Errorf("нет даты-исключения %s у задачи %d: %w", date, id, ErrNotFound)
+	}
+	return nil
+}
+
+// loadExceptions читает в рамках транзакции tx даты-исключения задачи для вычисления следующей даты.
+func (c *DBConnection) loadExceptions(tx *sql.Tx, id int) (map[string]bool, error) {
+	rows, err := tx.Query(c.dialect.Rebind(`SELECT date FROM task_exceptions WHERE task_id = ?`), id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	exceptions := make(map[string]bool)
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		exceptions[date] = true
+	}
+	return exceptions, rows.Err()
+}
+
+// sortedDates возвращает даты множества в порядке возрастания.
+func sortedDates(set map[string]bool) []string {
+	dates := make([]string, 0, len(set))
+	for date := range set {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"container/heap"
+	"time"
+)
+
+// MaxExpanded - наибольшее число повторений задач, которое вычисляют повестка и календарь за один запрос.
+const MaxExpanded = 1000
+
+// expansion вычисляет по одному повторения задачи с датами от from до to включительно - копии задачи с датой
+// повторения, правилом повторения и числом оставшихся повторений для неё. Первое повторение - сама задача,
+// если её дата в окне; каждое следующее вычисляется так, как если бы предыдущее выполнили в свой день:
+// с пропуском дат-исключений, переносом по политике Roll и окончанием по repeat_until, repeat_count, COUNT и UNTIL.
+// Если дата задачи раньше from, повторения отсчитываются от дня перед from; повторения раньше from пропускаются.
+type expansion struct {
+	from, to string
+	// current - последнее вычисленное повторение.
+	current Task
+	// start - день, после которого вычисляется следующее повторение.
+	start string
+	// pending - current ещё не возвращено методом next.
+	pending bool
+	done    bool
+}
+
+// newExpansion начинает вычисление повторений задачи t с датами от from до to.
+func newExpansion(t Task, from, to string) *expansion {
+	e := &expansion{from: from, to: to, current: t, start: t.Date, pending: t.Date >= from && t.Date <= to}
+	if t.Date < from {
+		day, err := time.Parse("20060102", from)
+		if err != nil {
+			e.done = true
+			return e
+		}
+		e.start = day.AddDate(0, 0, -1).Format("20060102")
+	}
+	return e
+}
+
+// next возвращает следующее повторение задачи или false, если повторений в окне больше нет.
+// Повторения перестают вычисляться, если следующую дату вычислить не удаётся или она не позже предыдущей.
+func (e *expansion) next() (Task, bool) {
+	if e.pending {
+		e.pending = false
+		return e.current, true
+	}
+	for !e.done && e.current.Repeat != "" {
+		now, err := time.ParseInLocation("20060102", e.start, e.current.Location())
+		if err != nil {
+			break
+		}
+		next, completed, err := e.current.afterDone(now)
+		if err != nil || completed || next.Date > e.to || next.Date <= e.current.Date {
+			break
+		}
+		e.current = next
+		if next.Date < e.from {
+			// правило "y" от дня перед from может вернуть этот же день
+			continue
+		}
+		e.start = next.Date
+		return next, true
+	}
+	e.done = true
+	return Task{}, false
+}
+
+// expansionHeap - очередь вычисляемых повторений задач, упорядоченная по ближайшему повторению, как sortByDate.
+type expansionHeap []expansionItem
+
+// expansionItem - ближайшее ещё не выданное повторение задачи и вычисление её следующих повторений.
+type expansionItem struct {
+	occurrence Task
+	expansion  *expansion
+}
+
+func (h expansionHeap) Len() int { return len(h) }
+
+func (h expansionHeap) Less(i, j int) bool {
+	query := TaskQuery{Sort: SortDate}
+	return query.compare(query.keyOf(h[i].occurrence), query.keyOf(h[j].occurrence)) < 0
+}
+
+func (h expansionHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *expansionHeap) Push(x any) { *h = append(*h, x.(expansionItem)) }
+
+func (h *expansionHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// expandAll сливает повторения задач с датами от from до to в порядке даты, времени и идентификатора и останавливается
+// на limit повторениях, поэтому на запрос вычисляется не больше одного повторения на задачу и limit следующих,
+// сколько бы задач и повторений ни было в окне.
+//
+// Параметры:
+// tasks - задачи с загруженными датами-исключениями.
+// from, to - границы окна в формате "20060102".
+// limit - наибольшее число повторений.
+//
+// Возвращает:
+// Не больше limit самых ранних повторений и признак того, что в окне есть повторения сверх limit.
+func expandAll(tasks []Task, from, to string, limit int) ([]Task, bool) {
+	h := make(expansionHeap, 0, len(tasks))
+	for _, task := range tasks {
+		e := newExpansion(task, from, to)
+		if occurrence, ok := e.next(); ok {
+			h = append(h, expansionItem{occurrence: occurrence, expansion: e})
+		}
+	}
+	heap.Init(&h)
+	occurrences := []Task{}
+	for h.Len() > 0 {
+		if len(occurrences) == limit {
+			return occurrences, true
+		}
+		occurrences = append(occurrences, h[0].occurrence)
+		if occurrence, ok := h[0].expansion.next(); ok {
+			h[0].occurrence = occurrence
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return occurrences, false
+}
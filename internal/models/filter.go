@@ -0,0 +1,289 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go_final_project/internal/utils"
+)
+
+// Поля условий поискового запроса.
+const (
+	filterText      = "text"
+	filterDate      = "due"
+	filterRepeating = "repeating"
+	filterOverdue   = "overdue"
+	filterTag       = "tag"
+	filterPriority  = "priority"
+)
+
+// likeEscaper экранирует символом `\` спецсимволы шаблона LIKE, чтобы текст условия искался буквально.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// filterKey разбирает условие вида "<поле><оператор><значение>", например "due<01.11.2026" или "tag:work".
+var filterKey = regexp.MustCompile(`^(due|date|from|to|repeating|overdue|tag|priority)(:|=|<=|>=|<|>)(.*)$`)
+
+// Filter - разобранный поисковый запрос: условия, которые должны выполняться одновременно.
+type Filter struct {
+	terms []filterTerm
+}
+
+// filterTerm - одно условие поискового запроса.
+type filterTerm struct {
+	field string
+	// op - оператор сравнения: "=", "<", "<=", ">" или ">=".
+	op string
+	// value - строка для поиска, дата в формате "20060102" или тег.
+	value string
+	// number - приоритет для условия priority.
+	number int
+	// flag - значение условий repeating и overdue.
+	flag bool
+}
+
+// ParseFilter разбирает поисковый запрос. Запрос состоит из слов, разделённых пробелами; все условия должны выполняться одновременно:
+//   - due:<дата>, due<<дата>, due<=<дата>, due><дата>, due>=<дата> - дата задачи равна, раньше или позже указанной;
+//     вместо due можно писать date;
+//   - from:<дата> и to:<дата> - дата задачи не раньше и не позже указанной;
+//   - repeating:true|false - задача повторяется или нет;
+//   - overdue:true|false - дата задачи раньше сегодняшней или нет;
+//   - tag:<тег> - у задачи есть тег;
+//   - priority:<N>, priority>=<N> и другие сравнения - приоритет задачи;
+//   - дата в формате "02.01.2006" - то же, что due:<дата>;
+//   - остальные слова и фразы в двойных кавычках ищутся в заголовке и комментарии.
+//
+// Даты в условиях указываются в формате "02.01.2006" или "20060102", вместо ":" можно писать "=".
+//
+// Параметры:
+// query - поисковый запрос, например `tag:work due<01.11.2026 созвон`.
+//
+// Возвращает:
+// Фильтр или ошибку с кодом invalid_request, если условие неверно.
+func ParseFilter(query string) (Filter, error) {
+	var filter Filter
+	for _, word := range splitQuery(query) {
+		if word.quoted {
+			filter.terms = append(filter.terms, filterTerm{field: filterText, value: word.text})
+			continue
+		}
+		if date, err := time.Parse("02.01.2006", word.text); err == nil {
+			filter.terms = append(filter.terms, filterTerm{field: filterDate, op: "=", value: date.Format("20060102")})
+			continue
+		}
+		match := filterKey.FindStringSubmatch(word.text)
+		if match == nil {
+			filter.terms = append(filter.terms, filterTerm{field: filterText, value: word.text})
+			continue
+		}
+		term, err := parseTerm(match[1], match[2], match[3])
+		if err != nil {
+			return Filter{}, err
+		}
+		filter.terms = append(filter.terms, term)
+	}
+	return filter, nil
+}
+
+// parseTerm разбирает условие с полем key, оператором op и значением value.
+func parseTerm(key, op, value string) (filterTerm, error) {
+	if op == ":" {
+		op = "="
+	}
+	if value == "" {
+		return filterTerm{}, utils.NewError(utils.CodeInvalidRequest, "search", "не указано значение условия %s", key)
+	}
+	if op != "=" && key != "due" && key != "date" && key != "priority" {
+		return filterTerm{}, utils.NewError(utils.CodeInvalidRequest, "search", "условие %s не поддерживает оператор %s", key, op)
+	}
+	term := filterTerm{field: key, op: op, value: value}
+	switch key {
+	case "due", "date", "from", "to":
+		date, err := parseFilterDate(value)
+		if err != nil {
+			return filterTerm{}, utils.NewError(utils.CodeInvalidRequest, "search", "неверная дата %s в условии %s", value, key)
+		}
+		term.field, term.value = filterDate, date
+		switch key {
+		case "from":
+			term.op = ">="
+		case "to":
+			term.op = "<="
+		}
+	case filterRepeating, filterOverdue:
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return filterTerm{}, utils.NewError(utils.CodeInvalidRequest, "search", "неверное значение %s в условии %s, ожидается true или false", value, key)
+		}
+		term.flag = flag
+	case filterPriority:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return filterTerm{}, utils.NewError(utils.CodeInvalidRequest, "search", "неверное значение %s в условии %s", value, key)
+		}
+		term.number = number
+	}
+	return term, nil
+}
+
+// parseFilterDate разбирает дату условия в формате "02.01.2006" или "20060102" и возвращает её в формате "20060102".
+func parseFilterDate(value string) (string, error) {
+	date, err := time.Parse("02.01.2006", value)
+	if err != nil {
+		date, err = time.Parse("20060102", value)
+	}
+	if err != nil {
+		return "", err
+	}
+	return date.Format("20060102"), nil
+}
+
+// queryWord - слово поискового запроса; quoted - слово было фразой в двойных кавычках.
+type queryWord struct {
+	text   string
+	quoted bool
+}
+
+// splitQuery делит поисковый запрос на слова по пробелам; фраза в двойных кавычках остаётся одним словом.
+func splitQuery(query string) []queryWord {
+	var words []queryWord
+	var word strings.Builder
+	quoted, inQuotes := false, false
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, queryWord{text: word.String(), quoted: quoted})
+		}
+		word.Reset()
+		quoted = false
+	}
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			quoted = true
+		case unicode.IsSpace(r) && !inQuotes:
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// taskSQL - часть запроса к scheduler, отбирающая задачи: присоединяемая таблица join и условие where,
+// которое дописывается к WHERE, с их аргументами.
+type taskSQL struct {
+	join     string
+	joinArgs []any
+	where    string
+	args     []any
+}
+
+// sql возвращает часть SQL-запроса, отбирающую задачи по условиям фильтра.
+// Если диалект поддерживает FTS5, текстовые условия проверяются по индексу scheduler_fts, который присоединяется
+// вместе со столбцом rank - оценкой релевантности bm25; иначе слова ищутся через LIKE.
+//
+// Параметры:
+// d - SQL-диалект базы данных.
+// today - сегодняшняя дата в формате "20060102" для условия overdue.
+func (f Filter) sql(d Dialect, today string) taskSQL {
+	var q taskSQL
+	var b strings.Builder
+	if expr := f.matchExpr(); expr != "" && d.fts {
+		q.join = fmt.Sprintf(` JOIN (SELECT rowid AS fts_id, bm25(scheduler_fts, %.1f, %.1f) AS rank FROM scheduler_fts
+		WHERE scheduler_fts MATCH ?) fts ON fts.fts_id = scheduler.id`, titleWeight, commentWeight)
+		q.joinArgs = []any{expr}
+	}
+	for _, term := range f.terms {
+		if term.field == filterText && q.join != "" {
+			continue
+		}
+		b.WriteString(" AND ")
+		switch term.field {
+		case filterText:
+			b.WriteString("(title " + d.like + ` ? ESCAPE '\' OR comment ` + d.like + ` ? ESCAPE '\')`)
+			pattern := "%" + likeEscaper.Replace(term.value) + "%"
+			q.args = append(q.args, pattern, pattern)
+		case filterDate:
+			b.WriteString("date " + term.op + " ?")
+			q.args = append(q.args, term.value)
+		case filterRepeating:
+			if term.flag {
+				b.WriteString("repeat <> ''")
+			} else {
+				b.WriteString("repeat = ''")
+			}
+		case filterOverdue:
+			if term.flag {
+				b.WriteString("date < ?")
+			} else {
+				b.WriteString("date >= ?")
+			}
+			q.args = append(q.args, today)
+		case filterTag:
+			b.WriteString(d.Contains("(',' || tags || ',')"))
+			q.args = append(q.args, ","+term.value+",")
+		case filterPriority:
+			b.WriteString("priority " + term.op + " ?")
+			q.args = append(q.args, term.number)
+		}
+	}
+	q.where = b.String()
+	return q
+}
+
+// matches проверяет, выполняются ли для задачи все условия фильтра.
+// Слова ищутся так же, как в индексе FTS5: без учёта регистра, последнее слово фразы - как начало слова.
+//
+// Параметры:
+// t - задача.
+// today - сегодняшняя дата в формате "20060102" для условия overdue.
+func (f Filter) matches(t Task, today string) bool {
+	if !f.textMatches(t) {
+		return false
+	}
+	for _, term := range f.terms {
+		ok := true
+		switch term.field {
+		case filterDate:
+			ok = compare(strings.Compare(t.Date, term.value), term.op)
+		case filterRepeating:
+			ok = (t.Repeat != "") == term.flag
+		case filterOverdue:
+			ok = (t.Date < today) == term.flag
+		case filterTag:
+			ok = strings.Contains(","+t.Tags+",", ","+term.value+",")
+		case filterPriority:
+			ok = compare(t.Priority-term.number, term.op)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compare проверяет, что результат сравнения c (отрицательный, 0 или положительный) удовлетворяет оператору op.
+func compare(c int, op string) bool {
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	default:
+		return c == 0
+	}
+}
+
+// filterToday возвращает сегодняшнюю дату в часовом поясе по умолчанию для условия overdue.
+func filterToday(now time.Time) string {
+	return Task{}.local(now).Format("20060102")
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Веса совпадений в названии и комментарии задачи при ранжировании результатов поиска.
+const (
+	titleWeight   = 10.0
+	commentWeight = 1.0
+)
+
+// snippetWords - сколько слов длинного комментария показывается во фрагменте результата поиска.
+const snippetWords = 16
+
+// textToken - слово текста в нижнем регистре и его границы в байтах исходного текста.
+type textToken struct {
+	word       string
+	start, end int
+}
+
+// tokenize делит текст на слова так же, как токенизатор unicode61 таблицы scheduler_fts: словом считается
+// последовательность букв и цифр, регистр любых букв не учитывается, а диакритические знаки учитываются
+// (индекс создаётся с remove_diacritics 0).
+func tokenize(text string) []textToken {
+	var tokens []textToken
+	start := -1
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, textToken{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, textToken{word: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// phrases возвращает слова каждого текстового условия фильтра. Условия без слов пропускаются.
+func (f Filter) phrases() [][]string {
+	var phrases [][]string
+	for _, term := range f.terms {
+		if term.field != filterText {
+			continue
+		}
+		var words []string
+		for _, token := range tokenize(term.value) {
+			words = append(words, token.word)
+		}
+		if len(words) > 0 {
+			phrases = append(phrases, words)
+		}
+	}
+	return phrases
+}
+
+// matchExpr возвращает запрос FTS5, который находит задачи со всеми текстовыми условиями фильтра:
+// каждое условие - фраза, последнее слово которой может быть началом слова текста.
+// Пустая строка означает, что текстовых условий нет.
+func (f Filter) matchExpr() string {
+	var parts []string
+	for _, words := range f.phrases() {
+		parts = append(parts, `"`+strings.Join(words, " ")+`"*`)
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// findPhrase возвращает границы в байтах всех вхождений фразы words в текст, разделённый на слова tokens.
+// Последнее слово фразы сравнивается как начало слова текста, остальные - целиком.
+func findPhrase(tokens []textToken, words []string) [][2]int {
+	var spans [][2]int
+	for i := 0; i+len(words) <= len(tokens); i++ {
+		found := true
+		for j, word := range words {
+			token := tokens[i+j].word
+			if j == len(words)-1 {
+				found = strings.HasPrefix(token, word)
+			} else {
+				found = token == word
+			}
+			if !found {
+				break
+			}
+		}
+		if found {
+			spans = append(spans, [2]int{tokens[i].start, tokens[i+len(words)-1].end})
+		}
+	}
+	return spans
+}
+
+// textMatches проверяет, что каждая фраза фильтра встречается в названии или комментарии задачи, как при поиске через FTS5.
+func (f Filter) textMatches(t Task) bool {
+	title, comment := tokenize(t.Title), tokenize(t.Comment)
+	for _, words := range f.phrases() {
+		if len(findPhrase(title, words)) == 0 && len(findPhrase(comment, words)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// rank оценивает релевантность задачи текстовым условиям фильтра: чем меньше значение, тем выше задача в результатах,
+// как у функции bm25 в FTS5. Совпадения в названии весят больше совпадений в комментарии.
+func (f Filter) rank(t Task) float64 {
+	title, comment := tokenize(t.Title), tokenize(t.Comment)
+	var score float64
+	for _, words := range f.phrases() {
+		score += titleWeight*float64(len(findPhrase(title, words))) + commentWeight*float64(len(findPhrase(comment, words)))
+	}
+	return -score
+}
+
+// snippet возвращает фрагмент названия или комментария задачи, в котором совпадения с текстовыми условиями фильтра
+// выделены тегами <mark>. Текст фрагмента экранирован для вставки в HTML, длинный комментарий сокращается
+// до snippetWords слов вокруг первого совпадения. Если совпадений нет, возвращается пустая строка.
+func (f Filter) snippet(t Task) string {
+	phrases := f.phrases()
+	for _, text := range []string{t.Title, t.Comment} {
+		tokens := tokenize(text)
+		var spans [][2]int
+		for _, words := range phrases {
+			spans = append(spans, findPhrase(tokens, words)...)
+		}
+		if len(spans) > 0 {
+			return highlight(text, tokens, spans)
+		}
+	}
+	return ""
+}
+
+// highlight экранирует текст и выделяет в нём фрагменты spans; если в тексте больше snippetWords слов,
+// показываются только слова вокруг первого фрагмента.
+func highlight(text string, tokens []textToken, spans [][2]int) string {
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	lo, hi := 0, len(text)
+	var b strings.Builder
+	if len(tokens) > snippetWords {
+		first := 0
+		for first < len(tokens) && tokens[first].start < spans[0][0] {
+			first++
+		}
+		from := max(0, min(first-snippetWords/4, len(tokens)-snippetWords))
+		to := from + snippetWords
+		if from > 0 {
+			lo = tokens[from].start
+			b.WriteString("…")
+		}
+		if to < len(tokens) {
+			hi = tokens[to-1].end
+		}
+	}
+	pos := lo
+	for _, span := range spans {
+		if span[0] < pos || span[1] > hi {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:span[0]]))
+		b.WriteString("<mark>" + html.EscapeString(text[span[0]:span[1]]) + "</mark>")
+		pos = span[1]
+	}
+	b.WriteString(html.EscapeString(text[pos:hi]))
+	if hi < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
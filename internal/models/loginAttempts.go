@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginAttempt - счётчик неудачных попыток входа для одного ключа: IP-адреса ("ip:<адрес>")
+// или пары IP-адреса и логина ("login:<хеш>").
+type LoginAttempt struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// LoadLoginAttempts извлекает все сохранённые счётчики неудачных попыток входа.
+//
+// Возвращает:
+// - Срез счётчиков и ошибку, если во время извлечения произошла ошибка.
+func (c *DBConnection) LoadLoginAttempts() ([]LoginAttempt, error) {
+	rows, err := c.query(`SELECT key, failures, last_failure, locked_until FROM login_attempts`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	attempts := []LoginAttempt{}
+	for rows.Next() {
+		var attempt LoginAttempt
+		var lastFailure, lockedUntil int64
+		err := rows.Scan(&attempt.Key, &attempt.Failures, &lastFailure, &lockedUntil)
+		if err != nil {
+			return nil, err
+		}
+		attempt.LastFailure = time.Unix(lastFailure, 0)
+		attempt.LockedUntil = time.Unix(lockedUntil, 0)
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// SaveLoginAttempt сохраняет счётчик неудачных попыток входа, заменяя предыдущее значение для того же ключа.
+//
+// Параметры:
+// - attempt: Сохраняемый счётчик.
+//
+// Возвращает:
+// - Ошибку, если во время записи произошла ошибка, иначе nil.
+func (c *DBConnection) SaveLoginAttempt(attempt LoginAttempt) error {
+	_, err := c.exec(`INSERT INTO login_attempts (key, failures, last_failure, locked_until) VALUES (?, ?, ?, ?)
+	ON CONFLICT (key) DO UPDATE SET failures = excluded.failures, last_failure = excluded.last_failure, locked_until = excluded.locked_until`,
+		attempt.Key, attempt.Failures, attempt.LastFailure.Unix(), attempt.LockedUntil.Unix())
+	return err
+}
+
+// DeleteLoginAttempt удаляет сохранённый счётчик неудачных попыток входа.
+//
+// Параметры:
+// - key: Ключ счётчика.
+//
+// Возвращает:
+// - Ошибку, если во время удаления произошла ошибка, иначе nil.
+func (c *DBConnection) DeleteLoginAttempt(key string) error {
+	_, err := c.exec(`DELETE FROM login_attempts WHERE key = ?`, key)
+	return err
+}
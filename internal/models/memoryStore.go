@@ -0,0 +1,600 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go_final_project/internal/utils"
+)
+
+// MemoryStore - хранилище задач и учётных записей в памяти процесса.
+// Используется в тестах и при встраивании планировщика; данные теряются при остановке.
+// Поведение методов совпадает с DBConnection.
+type MemoryStore struct {
+	mu       sync.Mutex
+	logger   *zap.SugaredLogger
+	clock    utils.Clock
+	tasks    map[int]memoryTask
+	lastID   int
+	users    map[int64]memoryUser
+	revoked  map[string]time.Time
+	attempts map[string]LoginAttempt
+	apiKeys  map[int64]memoryAPIKey
+	lastKey  int64
+	// completions - журналы выполнения задач по идентификатору задачи, от старых записей к новым
+	completions    map[int][]Completion
+	lastCompletion int64
+	// exceptions - даты-исключения задач по идентификатору задачи
+	exceptions map[int]map[string]bool
+}
+
+type memoryTask struct {
+	userID int64
+	task   Task
+}
+
+type memoryUser struct {
+	user User
+	hash string
+}
+
+type memoryAPIKey struct {
+	key        APIKey
+	hash       string
+	createdAt  time.Time
+	lastUsedAt time.Time
+}
+
+// NewMemoryStore создает пустое хранилище в памяти с пользователем по умолчанию.
+//
+// Параметры:
+// clock - Часы, по которым вычисляются даты задач и отметки времени.
+// logger - Указатель на объект zap.SugaredLogger для ведения журнала.
+//
+// Возвращает:
+// Указатель на новое хранилище.
+func NewMemoryStore(clock utils.Clock, logger *zap.SugaredLogger) *MemoryStore {
+	return &MemoryStore{
+		logger:      logger,
+		clock:       clock,
+		tasks:       make(map[int]memoryTask),
+		users:       map[int64]memoryUser{DefaultUserID: {user: User{ID: DefaultUserID, Login: "admin"}}},
+		revoked:     make(map[string]time.Time),
+		attempts:    make(map[string]LoginAttempt),
+		apiKeys:     make(map[int64]memoryAPIKey),
+		completions: make(map[int][]Completion),
+		exceptions:  make(map[int]map[string]bool),
+	}
+}
+
+// CheckID проверяет, существует ли задача с указанным идентификатором у указанного пользователя.
+func (m *MemoryStore) CheckID(userID int64, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.owned(userID, id); !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Insert сохраняет новую задачу пользователя и возвращает её идентификатор.
+func (m *MemoryStore) Insert(userID int64, task *Task) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastID++
+	stored := *task
+	stored.ID = strconv.Itoa(m.lastID)
+	stored.Version = 1
+	stored.CompletedAt = ""
+	m.tasks[m.lastID] = memoryTask{userID: userID, task: stored}
+	m.logger.Infof("Task inserted with ID: %d", m.lastID)
+	return m.lastID, nil
+}
+
+// Update обновляет данные существующей задачи пользователя.
+// Если в задаче указана версия, она должна совпадать с сохранённой, иначе возвращается ErrConflict.
+func (m *MemoryStore) Update(userID int64, task *Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id, err := strconv.Atoi(task.ID)
+	if err != nil {
+		return err
+	}
+	stored, ok := m.owned(userID, id)
+	if !ok {
+		return ErrNotFound
+	}
+	if task.Version != 0 && task.Version != stored.Version {
+		return ErrConflict
+	}
+	updated := *task
+	updated.Version = stored.Version + 1
+	updated.CompletedAt = stored.CompletedAt
+	updated.ScheduleDate = ""
+	if updated.Date == stored.Date {
+		updated.ScheduleDate = stored.ScheduleDate
+	}
+	m.tasks[id] = memoryTask{userID: userID, task: updated}
+	m.logger.Infof("Task `%s` updated", task.Title)
+	return nil
+}
+
+// UpdateDate обновляет дату существующей задачи.
+func (m *MemoryStore) UpdateDate(task *Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id, err := strconv.Atoi(task.ID)
+	if err != nil {
+		return err
+	}
+	stored, ok := m.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.task.Date = task.Date
+	stored.task.Version++
+	m.tasks[id] = stored
+	m.logger.Infof("date of task `%s` updated", task.Title)
+	return nil
+}
+
+// Delete удаляет задачу пользователя.
+func (m *MemoryStore) Delete(userID int64, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.owned(userID, id); !ok {
+		return ErrNotFound
+	}
+	delete(m.tasks, id)
+	delete(m.completions, id)
+	delete(m.exceptions, id)
+	m.logger.Infof("Task with ID: %d was deleted", id)
+	return nil
+}
+
+// GetTask возвращает задачу пользователя по идентификатору.
+func (m *MemoryStore) GetTask(userID int64, id int) (*Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task, ok := m.owned(userID, id)
+	if !ok {
+		return nil, utils.Errorf("задача %d: %w", id, ErrNotFound)
+	}
+	return &task, nil
+}
+
+// GetAll возвращает страницу задач пользователя в порядке и после курсора выборки query.
+func (m *MemoryStore) GetAll(userID int64, query TaskQuery) (TaskPage, error) {
+	return m.filter(userID, query, func(*Task) bool { return true }), nil
+}
+
+// Between возвращает активные задачи пользователя, у которых могут быть повторения с датами от from до to,
+// с датами-исключениями.
+func (m *MemoryStore) Between(userID int64, from, to string) ([]Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tasks := []Task{}
+	for id, stored := range m.tasks {
+		task := stored.task
+		if stored.userID != userID || !StatusActive.matches(task) || task.Date > to || task.Date < from && task.Repeat == "" {
+			continue
+		}
+		if len(m.exceptions[id]) > 0 {
+			task.Exceptions = make(map[string]bool, len(m.exceptions[id]))
+			for date := range m.exceptions[id] {
+				task.Exceptions[date] = true
+			}
+		}
+		tasks = append(tasks, task)
+	}
+	sortByDate(tasks)
+	return tasks, nil
+}
+
+// Search ищет задачи пользователя по поисковому запросу, разобранному функцией ParseFilter.
+// Слова ищутся и ранжируются так же, как через индекс FTS5 в DBConnection, хотя оценки релевантности отличаются от bm25.
+func (m *MemoryStore) Search(userID int64, key string, query TaskQuery) (TaskPage, error) {
+	filter, err := ParseFilter(key)
+	if err != nil {
+		return TaskPage{}, err
+	}
+	today := filterToday(m.clock.Now())
+	query.ranked = len(filter.phrases()) > 0
+	page := m.filter(userID, query, func(t *Task) bool {
+		if !filter.matches(*t, today) {
+			return false
+		}
+		t.rank = filter.rank(*t)
+		return true
+	})
+	for i := range page.Tasks {
+		page.Tasks[i].Snippet = filter.snippet(page.Tasks[i])
+	}
+	return page, nil
+}
+
+// Done помечает задачу пользователя как выполненную: повторяющаяся задача переносится на следующую дату,
+// одноразовые задачи и задачи с закончившимися повторениями получают отметку времени выполнения. Выполненное повторение записывается в журнал выполнения с заметкой note.
+// Задача читается и изменяется под одной блокировкой; если version не 0, он должен совпадать с версией задачи,
+// а если date не пуста - с датой задачи.
+func (m *MemoryStore) Done(userID int64, id int, version int, date, note string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task, ok := m.owned(userID, id)
+	if !ok {
+		return ErrNotFound
+	}
+	if err := task.checkDone(version, date); err != nil {
+		return err
+	}
+	task.Exceptions = m.exceptions[id]
+	now := m.clock.Now()
+	next, completed, err := task.afterDone(now)
+	if err != nil {
+		m.logger.Error(err)
+		return err
+	}
+	m.recordCompletion(id, task.Date, note, now.In(task.Location()))
+	m.advance(id, next, completed, now)
+	if completed {
+		m.logger.Infof("Task `%s` done and completed", task.Title)
+		return nil
+	}
+	m.logger.Infof("Task `%s` done", task.Title)
+	return nil
+}
+
+// Skip добавляет дату-исключение повторяющейся задаче пользователя; если дата совпадает с датой задачи,
+// задача переносится на следующее повторение без записи в журнал выполнения.
+func (m *MemoryStore) Skip(userID int64, id int, date string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task, ok := m.owned(userID, id)
+	if !ok {
+		return ErrNotFound
+	}
+	if task.Repeat == "" {
+		return ErrNotRepeating
+	}
+	if task.CompletedAt != "" {
+		return utils.Errorf("%w: задача уже выполнена", ErrConflict)
+	}
+	if date == "" {
+		date = task.Date
+	}
+	exceptions := make(map[string]bool, len(m.exceptions[id])+1)
+	for skipped := range m.exceptions[id] {
+		exceptions[skipped] = true
+	}
+	exceptions[date] = true
+	if date == task.Date {
+		task.Exceptions = exceptions
+		now := m.clock.Now()
+		next, err := task.afterSkip(now)
+		if err != nil {
+			return err
+		}
+		m.advance(id, next, false, now)
+		m.logger.Infof("Task `%s` skipped", task.Title)
+	}
+	m.exceptions[id] = exceptions
+	return nil
+}
+
+// Exceptions возвращает даты-исключения задачи пользователя в порядке возрастания.
+func (m *MemoryStore) Exceptions(userID int64, id int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.owned(userID, id); !ok {
+		return nil, ErrNotFound
+	}
+	return sortedDates(m.exceptions[id]), nil
+}
+
+// DeleteException удаляет дату-исключение задачи пользователя.
+func (m *MemoryStore) DeleteException(userID int64, id int, date string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.owned(userID, id); !ok || !m.exceptions[id][date] {
+		return utils.Errorf("нет даты-исключения %s у задачи %d: %w", date, id, ErrNotFound)
+	}
+	delete(m.exceptions[id], date)
+	return nil
+}
+
+// advance сохраняет состояние задачи id после выполнения или пропуска повторения. Вызывается под блокировкой.
+func (m *MemoryStore) advance(id int, next Task, completed bool, now time.Time) {
+	stored := m.tasks[id]
+	stored.task.Version++
+	if completed {
+		stored.task.CompletedAt = now.In(stored.task.Location()).Format(time.RFC3339)
+	} else {
+		stored.task.Date = next.Date
+		stored.task.ScheduleDate = next.ScheduleDate
+		stored.task.Repeat = next.Repeat
+		stored.task.RepeatCount = next.RepeatCount
+	}
+	m.tasks[id] = stored
+}
+
+// Undone снимает с выполненной задачи пользователя отметку о выполнении.
+func (m *MemoryStore) Undone(userID int64, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	task, ok := m.owned(userID, id)
+	if !ok {
+		return ErrNotFound
+	}
+	if task.CompletedAt == "" {
+		return utils.Errorf("%w: задача не выполнена", ErrConflict)
+	}
+	stored := m.tasks[id]
+	stored.task.CompletedAt = ""
+	stored.task.Version++
+	m.tasks[id] = stored
+	if completions := m.completions[id]; len(completions) > 0 {
+		m.completions[id] = completions[:len(completions)-1]
+	}
+	m.logger.Infof("Task with ID: %d was reopened", id)
+	return nil
+}
+
+// PurgeCompleted удаляет задачи всех пользователей, выполненные раньше момента before.
+func (m *MemoryStore) PurgeCompleted(before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var purged int64
+	for id, stored := range m.tasks {
+		if stored.task.CompletedAt == "" {
+			continue
+		}
+		completedAt, err := time.Parse(time.RFC3339, stored.task.CompletedAt)
+		if err != nil {
+			return purged, err
+		}
+		if completedAt.Before(before) {
+			delete(m.tasks, id)
+			delete(m.completions, id)
+			delete(m.exceptions, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// History возвращает журнал выполнения задачи пользователя от новых записей к старым.
+func (m *MemoryStore) History(userID int64, id int) ([]Completion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.owned(userID, id); !ok {
+		return nil, ErrNotFound
+	}
+	stored := m.completions[id]
+	completions := make([]Completion, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		completions = append(completions, stored[i])
+	}
+	return completions, nil
+}
+
+// recordCompletion добавляет запись в журнал выполнения задачи id. Вызывается под блокировкой.
+func (m *MemoryStore) recordCompletion(id int, date, note string, completedAt time.Time) {
+	m.lastCompletion++
+	m.completions[id] = append(m.completions[id], Completion{
+		ID:          m.lastCompletion,
+		Date:        date,
+		CompletedAt: completedAt.Format(time.RFC3339),
+		Note:        note,
+	})
+}
+
+// owned возвращает задачу id, если она принадлежит пользователю userID. Вызывается под блокировкой.
+func (m *MemoryStore) owned(userID int64, id int) (Task, bool) {
+	stored, ok := m.tasks[id]
+	if !ok || stored.userID != userID {
+		return Task{}, false
+	}
+	return stored.task, true
+}
+
+// filter возвращает страницу задач пользователя со статусом query.Status, удовлетворяющих match, в порядке и после курсора query.
+// Функция match может дополнить копию задачи, например вычислить её релевантность.
+func (m *MemoryStore) filter(userID int64, query TaskQuery, match func(*Task) bool) TaskPage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tasks []Task
+	for _, stored := range m.tasks {
+		task := stored.task
+		if stored.userID == userID && query.Status.matches(task) && match(&task) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return query.compare(query.keyOf(tasks[i]), query.keyOf(tasks[j])) < 0
+	})
+	total := len(tasks)
+	if query.after != nil {
+		tasks = tasks[sort.Search(len(tasks), func(i int) bool {
+			return query.compare(query.keyOf(tasks[i]), *query.after) > 0
+		}):]
+	}
+	return query.page(tasks[:min(len(tasks), query.Limit+1)], total)
+}
+
+// CreateUser регистрирует нового пользователя.
+func (m *MemoryStore) CreateUser(login, password string) (*User, error) {
+	hash, err := hashUserPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var lastID int64
+	for id, stored := range m.users {
+		if stored.user.Login == login {
+			return nil, ErrUserExists
+		}
+		lastID = max(lastID, id)
+	}
+	user := User{ID: lastID + 1, Login: login}
+	m.users[user.ID] = memoryUser{user: user, hash: hash}
+	m.logger.Infof("user `%s` registered with ID: %d", login, user.ID)
+	return &user, nil
+}
+
+// Authenticate проверяет имя и пароль пользователя.
+func (m *MemoryStore) Authenticate(login, password string) (*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, stored := range m.users {
+		if stored.user.Login != login {
+			continue
+		}
+		if stored.hash == "" || !checkUserPassword(stored.hash, password) {
+			return nil, ErrInvalidCredentials
+		}
+		user := stored.user
+		return &user, nil
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// RevokeToken добавляет идентификатор токена в список отозванных и удаляет из него истёкшие токены.
+func (m *MemoryStore) RevokeToken(jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now()
+	for revoked, exp := range m.revoked {
+		if exp.Before(now) {
+			delete(m.revoked, revoked)
+		}
+	}
+	m.revoked[jti] = expiresAt
+	return nil
+}
+
+// IsRevoked проверяет, был ли токен отозван.
+func (m *MemoryStore) IsRevoked(jti string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.revoked[jti]
+	return ok, nil
+}
+
+// LoadLoginAttempts возвращает все сохранённые счётчики неудачных попыток входа.
+func (m *MemoryStore) LoadLoginAttempts() ([]LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	attempts := make([]LoginAttempt, 0, len(m.attempts))
+	for _, attempt := range m.attempts {
+		attempts = append(attempts, attempt)
+	}
+	return attempts, nil
+}
+
+// SaveLoginAttempt сохраняет счётчик неудачных попыток входа.
+func (m *MemoryStore) SaveLoginAttempt(attempt LoginAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts[attempt.Key] = attempt
+	return nil
+}
+
+// DeleteLoginAttempt удаляет сохранённый счётчик неудачных попыток входа.
+func (m *MemoryStore) DeleteLoginAttempt(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, key)
+	return nil
+}
+
+// CreateAPIKey создаёт новый API-ключ пользователя и возвращает его описание и значение.
+func (m *MemoryStore) CreateAPIKey(userID int64, name, scope string) (*APIKey, string, error) {
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", err
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(random)
+	now := m.clock.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastKey++
+	key := APIKey{
+		ID:        m.lastKey,
+		UserID:    userID,
+		Name:      name,
+		Prefix:    secret[len(apiKeyPrefix) : len(apiKeyPrefix)+8],
+		Scope:     scope,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+	m.apiKeys[key.ID] = memoryAPIKey{key: key, hash: hashAPIKey(secret), createdAt: now}
+	return &key, secret, nil
+}
+
+// ListAPIKeys возвращает все API-ключи пользователя.
+func (m *MemoryStore) ListAPIKeys(userID int64) ([]APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := []APIKey{}
+	for _, stored := range m.apiKeys {
+		if stored.key.UserID == userID {
+			keys = append(keys, stored.key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+// DeleteAPIKey отзывает API-ключ пользователя.
+func (m *MemoryStore) DeleteAPIKey(userID, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.apiKeys[id]
+	if !ok || stored.key.UserID != userID {
+		return ErrNotFound
+	}
+	delete(m.apiKeys, id)
+	return nil
+}
+
+// PruneAPIKeys удаляет ключи пользователя, которые не использовались с момента before.
+func (m *MemoryStore) PruneAPIKeys(userID int64, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deleted int64
+	for id, stored := range m.apiKeys {
+		lastUse := stored.lastUsedAt
+		if lastUse.IsZero() {
+			lastUse = stored.createdAt
+		}
+		if stored.key.UserID == userID && lastUse.Before(before) {
+			delete(m.apiKeys, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// UseAPIKey находит ключ по его значению и отмечает время его использования.
+func (m *MemoryStore) UseAPIKey(secret string) (*APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash := hashAPIKey(secret)
+	for id, stored := range m.apiKeys {
+		if stored.hash != hash {
+			continue
+		}
+		stored.lastUsedAt = m.clock.Now()
+		stored.key.LastUsedAt = stored.lastUsedAt.Format(time.RFC3339)
+		m.apiKeys[id] = stored
+		key := stored.key
+		return &key, nil
+	}
+	return nil, ErrInvalidAPIKey
+}
@@ -0,0 +1,447 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration - один шаг изменения схемы базы данных.
+// Шаги применяются по возрастанию версии, каждый в отдельной транзакции.
+// Шаги up написаны так, чтобы их можно было применить и к базе данных, созданной до появления миграций.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx, d Dialect) error
+	down    func(tx *sql.Tx, d Dialect) error
+}
+
+// MigrationStatus - состояние одной миграции.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// migrations - упорядоченный список всех миграций. Новые миграции добавляются только в конец.
+// В запросах "{{serial}}" заменяется на тип автоинкрементного ключа диалекта.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create scheduler",
+		up: execAll(`CREATE TABLE IF NOT EXISTS scheduler (
+			id      {{serial}},
+			date    CHAR(8) NOT NULL DEFAULT '',
+			title   VARCHAR(128) NOT NULL DEFAULT '',
+			comment TEXT,
+			repeat  VARCHAR(128) NOT NULL DEFAULT ''
+			);`,
+			`CREATE INDEX IF NOT EXISTS taks_date ON scheduler (date);`),
+		down: execAll(`DROP TABLE scheduler;`),
+	},
+	{
+		version: 2,
+		name:    "create revoked_tokens",
+		up: execAll(`CREATE TABLE IF NOT EXISTS revoked_tokens (
+			jti        VARCHAR(64) PRIMARY KEY,
+			expires_at BIGINT NOT NULL
+			);`),
+		down: execAll(`DROP TABLE revoked_tokens;`),
+	},
+	{
+		version: 3,
+		name:    "create users and task owners",
+		up: func(tx *sql.Tx, d Dialect) error {
+			err := execAll(`CREATE TABLE IF NOT EXISTS users (
+				id            {{serial}},
+				login         VARCHAR(64) NOT NULL UNIQUE,
+				password_hash VARCHAR(256) NOT NULL DEFAULT '',
+				created_at    BIGINT NOT NULL DEFAULT 0
+				);`)(tx, d)
+			if err != nil {
+				return err
+			}
+			// Пользователь по умолчанию создаётся первым и поэтому получает идентификатор DefaultUserID
+			_, err = tx.Exec(d.Rebind(`INSERT INTO users (login, created_at) VALUES ('admin', ?) ON CONFLICT (login) DO NOTHING`),
+				time.Now().Unix())
+			if err != nil {
+				return err
+			}
+			err = addColumn(tx, d, "scheduler", "user_id",
+				fmt.Sprintf("INTEGER NOT NULL DEFAULT %d REFERENCES users (id)", DefaultUserID))
+			if err != nil {
+				return err
+			}
+			return execAll(`CREATE INDEX IF NOT EXISTS task_user ON scheduler (user_id, date);`)(tx, d)
+		},
+		down: func(tx *sql.Tx, d Dialect) error {
+			if d.Name != SQLite.Name {
+				return execAll(`ALTER TABLE scheduler DROP COLUMN user_id;`, `DROP TABLE users;`)(tx, d)
+			}
+			// SQLite не удаляет через ALTER TABLE столбец, участвующий во внешнем ключе, поэтому таблица пересоздаётся
+			return execAll(`CREATE TABLE scheduler_down (
+				id      {{serial}},
+				date    CHAR(8) NOT NULL DEFAULT '',
+				title   VARCHAR(128) NOT NULL DEFAULT '',
+				comment TEXT,
+				repeat  VARCHAR(128) NOT NULL DEFAULT ''
+				);`,
+				`INSERT INTO scheduler_down (id, date, title, comment, repeat) SELECT id, date, title, comment, repeat FROM scheduler;`,
+				`DROP TABLE scheduler;`,
+				`ALTER TABLE scheduler_down RENAME TO scheduler;`,
+				`CREATE INDEX taks_date ON scheduler (date);`,
+				`DROP TABLE users;`)(tx, d)
+		},
+	},
+	{
+		version: 4,
+		name:    "create login_attempts",
+		up: execAll(`CREATE TABLE IF NOT EXISTS login_attempts (
+			key          VARCHAR(64) PRIMARY KEY,
+			failures     INTEGER NOT NULL DEFAULT 0,
+			last_failure BIGINT NOT NULL DEFAULT 0,
+			locked_until BIGINT NOT NULL DEFAULT 0
+			);`),
+		down: execAll(`DROP TABLE login_attempts;`),
+	},
+	{
+		version: 5,
+		name:    "create api_keys",
+		up: execAll(`CREATE TABLE IF NOT EXISTS api_keys (
+			id           {{serial}},
+			user_id      INTEGER NOT NULL REFERENCES users (id),
+			name         VARCHAR(128) NOT NULL DEFAULT '',
+			prefix       CHAR(8) NOT NULL,
+			key_hash     CHAR(64) NOT NULL UNIQUE,
+			scope        VARCHAR(16) NOT NULL,
+			created_at   BIGINT NOT NULL,
+			last_used_at BIGINT
+			);`),
+		down: execAll(`DROP TABLE api_keys;`),
+	},
+	{
+		version: 6,
+		name:    "add scheduler version",
+		up: func(tx *sql.Tx, d Dialect) error {
+			return addColumn(tx, d, "scheduler", "version", "INTEGER NOT NULL DEFAULT 1")
+		},
+		down: execAll(`ALTER TABLE scheduler DROP COLUMN version;`),
+	},
+	{
+		version: 7,
+		name:    "add scheduler completed_at",
+		up: func(tx *sql.Tx, d Dialect) error {
+			err := addColumn(tx, d, "scheduler", "completed_at", "BIGINT NOT NULL DEFAULT 0")
+			if err != nil {
+				return err
+			}
+			return execAll(`CREATE INDEX IF NOT EXISTS task_completed ON scheduler (completed_at);`)(tx, d)
+		},
+		down: execAll(`DROP INDEX task_completed;`, `ALTER TABLE scheduler DROP COLUMN completed_at;`),
+	},
+	{
+		version: 8,
+		name:    "create task_completions",
+		up: execAll(`CREATE TABLE IF NOT EXISTS task_completions (
+			id              {{serial}},
+			task_id         INTEGER NOT NULL REFERENCES scheduler (id),
+			user_id         INTEGER NOT NULL REFERENCES users (id),
+			occurrence_date CHAR(8) NOT NULL,
+			completed_at    BIGINT NOT NULL,
+			note            TEXT NOT NULL DEFAULT ''
+			);`,
+			`CREATE INDEX IF NOT EXISTS completion_task ON task_completions (task_id, completed_at);`),
+		down: execAll(`DROP TABLE task_completions;`),
+	},
+	{
+		version: 9,
+		name:    "widen scheduler repeat",
+		// Правила RRULE длиннее 128 символов; SQLite не ограничивает длину VARCHAR, поэтому меняется только Postgres
+		up: func(tx *sql.Tx, d Dialect) error {
+			if d.Name == SQLite.Name {
+				return nil
+			}
+			return execAll(`ALTER TABLE scheduler ALTER COLUMN repeat TYPE VARCHAR(512);`)(tx, d)
+		},
+		down: func(tx *sql.Tx, d Dialect) error {
+			if d.Name == SQLite.Name {
+				return nil
+			}
+			return execAll(`ALTER TABLE scheduler ALTER COLUMN repeat TYPE VARCHAR(128);`)(tx, d)
+		},
+	},
+	{
+		version: 10,
+		name:    "add scheduler repeat end",
+		up: func(tx *sql.Tx, d Dialect) error {
+			err := addColumn(tx, d, "scheduler", "repeat_until", "CHAR(8) NOT NULL DEFAULT ''")
+			if err != nil {
+				return err
+			}
+			return addColumn(tx, d, "scheduler", "repeat_count", "INTEGER NOT NULL DEFAULT 0")
+		},
+		down: execAll(`ALTER TABLE scheduler DROP COLUMN repeat_count;`, `ALTER TABLE scheduler DROP COLUMN repeat_until;`),
+	},
+	{
+		version: 11,
+		name:    "create task_exceptions",
+		up: execAll(`CREATE TABLE IF NOT EXISTS task_exceptions (
+			task_id INTEGER NOT NULL REFERENCES scheduler (id),
+			user_id INTEGER NOT NULL REFERENCES users (id),
+			date    CHAR(8) NOT NULL,
+			PRIMARY KEY (task_id, date)
+			);`),
+		down: execAll(`DROP TABLE task_exceptions;`),
+	},
+	{
+		version: 12,
+		name:    "add scheduler roll",
+		up: func(tx *sql.Tx, d Dialect) error {
+			return addColumn(tx, d, "scheduler", "roll", "VARCHAR(8) NOT NULL DEFAULT ''")
+		},
+		down: execAll(`ALTER TABLE scheduler DROP COLUMN roll;`),
+	},
+	{
+		version: 13,
+		name:    "add scheduler due time and timezone",
+		up: func(tx *sql.Tx, d Dialect) error {
+			err := addColumn(tx, d, "scheduler", "due_time", "CHAR(5) NOT NULL DEFAULT ''")
+			if err != nil {
+				return err
+			}
+			return addColumn(tx, d, "scheduler", "timezone", "VARCHAR(64) NOT NULL DEFAULT ''")
+		},
+		down: execAll(`ALTER TABLE scheduler DROP COLUMN timezone;`, `ALTER TABLE scheduler DROP COLUMN due_time;`),
+	},
+	{
+		version: 14,
+		name:    "add scheduler priority",
+		up: func(tx *sql.Tx, d Dialect) error {
+			return addColumn(tx, d, "scheduler", "priority", "INTEGER NOT NULL DEFAULT 0")
+		},
+		down: execAll(`ALTER TABLE scheduler DROP COLUMN priority;`),
+	},
+	{
+		version: 15,
+		name:    "add scheduler tags",
+		up: func(tx *sql.Tx, d Dialect) error {
+			return addColumn(tx, d, "scheduler", "tags", "VARCHAR(512) NOT NULL DEFAULT ''")
+		},
+		down: execAll(`ALTER TABLE scheduler DROP COLUMN tags;`),
+	},
+	{
+		// Индекс полнотекстового поиска хранит копию названия и комментария задачи с rowid, равным id задачи.
+		// Его обновляют методы DBConnection, а не триггеры, чтобы таблица scheduler оставалась доступной для записи
+		// клиентам SQLite, собранным без FTS5. В PostgreSQL FTS5 нет, и поиск по словам выполняется через ILIKE.
+		version: 16,
+		name:    "create scheduler full-text index",
+		up: ftsOnly(`CREATE VIRTUAL TABLE IF NOT EXISTS scheduler_fts USING fts5(title, comment, tokenize='unicode61');`,
+			`INSERT INTO scheduler_fts (rowid, title, comment) SELECT id, title, COALESCE(comment, '') FROM scheduler;`),
+		down: ftsOnly(`DROP TABLE IF EXISTS scheduler_fts;`),
+	},
+	{
+		version: 17,
+		name:    "add scheduler schedule_date",
+		up: func(tx *sql.Tx, d Dialect) error {
+			return addColumn(tx, d, "scheduler", "schedule_date", "VARCHAR(8) NOT NULL DEFAULT ''")
+		},
+		down: execAll(`ALTER TABLE scheduler DROP COLUMN schedule_date;`),
+	},
+	{
+		version: 18,
+		name:    "unpad scheduler dates",
+		// PostgreSQL дополняет значения CHAR(n) пробелами до длины n, и пустые дата, дата окончания повторений и время
+		// читались бы как пробелы; в SQLite CHAR(n) не дополняется, поэтому меняется только Postgres
+		up: func(tx *sql.Tx, d Dialect) error {
+			if d.Name == SQLite.Name {
+				return nil
+			}
+			return execAll(`ALTER TABLE scheduler ALTER COLUMN date TYPE VARCHAR(8);`,
+				`ALTER TABLE scheduler ALTER COLUMN repeat_until TYPE VARCHAR(8);`,
+				`ALTER TABLE scheduler ALTER COLUMN due_time TYPE VARCHAR(5);`)(tx, d)
+		},
+		down: func(tx *sql.Tx, d Dialect) error {
+			if d.Name == SQLite.Name {
+				return nil
+			}
+			return execAll(`ALTER TABLE scheduler ALTER COLUMN due_time TYPE CHAR(5);`,
+				`ALTER TABLE scheduler ALTER COLUMN repeat_until TYPE CHAR(8);`,
+				`ALTER TABLE scheduler ALTER COLUMN date TYPE CHAR(8);`)(tx, d)
+		},
+	},
+	{
+		// По умолчанию unicode61 удаляет диакритические знаки, и "cafe" находило бы "café", хотя фрагменты результатов,
+		// поиск в хранилище в памяти и ILIKE в PostgreSQL сравнивают слова с диакритикой как есть
+		version: 19,
+		name:    "keep diacritics in scheduler full-text index",
+		up: ftsOnly(`DROP TABLE IF EXISTS scheduler_fts;`,
+			`CREATE VIRTUAL TABLE scheduler_fts USING fts5(title, comment, tokenize='unicode61 remove_diacritics 0');`,
+			`INSERT INTO scheduler_fts (rowid, title, comment) SELECT id, title, COALESCE(comment, '') FROM scheduler;`),
+		down: ftsOnly(`DROP TABLE IF EXISTS scheduler_fts;`,
+			`CREATE VIRTUAL TABLE scheduler_fts USING fts5(title, comment, tokenize='unicode61');`,
+			`INSERT INTO scheduler_fts (rowid, title, comment) SELECT id, title, COALESCE(comment, '') FROM scheduler;`),
+	},
+}
+
+// Migrate применяет все ещё не применённые миграции по возрастанию версии.
+// Вызывается при каждом запуске сервера, поэтому существующие файлы базы данных обновляются на месте.
+//
+// Возвращает:
+// - Ошибку, если какая-либо миграция завершилась неудачно. Уже применённые до неё миграции остаются в силе.
+func (c *DBConnection) Migrate() error {
+	applied, err := c.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+		err = c.inTx(func(tx *sql.Tx) error {
+			if err := m.up(tx, c.dialect); err != nil {
+				return err
+			}
+			_, err := tx.Exec(c.dialect.Rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+				m.version, m.name, time.Now().Unix())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		c.logger.Infof("migration %d (%s) applied", m.version, m.name)
+	}
+	return nil
+}
+
+// MigrateDown откатывает последнюю применённую миграцию.
+//
+// Возвращает:
+// - Ошибку, если применённых миграций нет или откат завершился неудачно.
+func (c *DBConnection) MigrateDown() error {
+	applied, err := c.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.version]; !ok {
+			continue
+		}
+		err = c.inTx(func(tx *sql.Tx) error {
+			if err := m.down(tx, c.dialect); err != nil {
+				return err
+			}
+			_, err := tx.Exec(c.dialect.Rebind(`DELETE FROM schema_migrations WHERE version = ?`), m.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		c.logger.Infof("migration %d (%s) reverted", m.version, m.name)
+		return nil
+	}
+	return fmt.Errorf("no applied migrations")
+}
+
+// MigrationStatus возвращает состояние всех известных миграций.
+//
+// Возвращает:
+// - Срез состояний по возрастанию версии и ошибку, если прочитать таблицу schema_migrations не удалось.
+func (c *DBConnection) MigrationStatus() ([]MigrationStatus, error) {
+	applied, err := c.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.version]
+		statuses = append(statuses, MigrationStatus{
+			Version:   m.version,
+			Name:      m.name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// appliedMigrations создаёт таблицу schema_migrations, если её нет, и возвращает версии применённых миграций
+// вместе со временем их применения.
+func (c *DBConnection) appliedMigrations() (map[int]time.Time, error) {
+	_, err := c.exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       VARCHAR(128) NOT NULL,
+		applied_at BIGINT NOT NULL
+		);`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt int64
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = time.Unix(appliedAt, 0)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// inTx выполняет fn в транзакции. Если fn вернула ошибку, транзакция откатывается.
+func (c *DBConnection) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// execAll возвращает шаг миграции, который по очереди выполняет запросы queries, адаптированные под диалект.
+func execAll(queries ...string) func(tx *sql.Tx, d Dialect) error {
+	return func(tx *sql.Tx, d Dialect) error {
+		for _, query := range queries {
+			if _, err := tx.Exec(d.Rebind(d.DDL(query))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ftsOnly возвращает шаг миграции, выполняющий запросы queries только в диалектах с поддержкой FTS5.
+func ftsOnly(queries ...string) func(tx *sql.Tx, d Dialect) error {
+	return func(tx *sql.Tx, d Dialect) error {
+		if !d.fts {
+			return nil
+		}
+		return execAll(queries...)(tx, d)
+	}
+}
+
+// addColumn добавляет в таблицу table столбец column с определением definition, если такого столбца ещё нет.
+func addColumn(tx *sql.Tx, d Dialect, table, column, definition string) error {
+	var count int
+	err := tx.QueryRow(d.Rebind(d.columnExists), table, column).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
@@ -1,11 +1,14 @@
 package models
 
 import (
-	"fmt"
+	"encoding/json"
+	"errors"
 	"go_final_project/internal/utils"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Task struct {
@@ -14,6 +17,186 @@ type Task struct {
 	Date    string `json:"date"`
 	Repeat  string `json:"repeat"`
 	Comment string `json:"comment"`
+	// Version увеличивается при каждом изменении задачи и используется для проверки конкурентных изменений.
+	Version int `json:"version,string,omitempty"`
+	// CompletedAt - время выполнения одноразовой задачи в формате RFC 3339; пусто у активных задач.
+	CompletedAt string `json:"completed_at,omitempty"`
+	// RepeatUntil - необязательная дата в формате "20060102", после которой задача больше не повторяется.
+	RepeatUntil string `json:"repeat_until,omitempty"`
+	// RepeatCount - необязательное число оставшихся повторений, считая текущую дату; 0 - без ограничения.
+	RepeatCount int `json:"repeat_count,string,omitempty"`
+	// Roll - политика переноса даты, выпавшей на выходной или праздник: "forward", "backward" или пусто (без переноса).
+	Roll string `json:"roll,omitempty"`
+	// ScheduleDate - дата повторения по правилу повторения, если Date получена из неё переносом по политике Roll;
+	// пусто, если дата не переносилась. Следующее повторение вычисляется от неё, поэтому перенос не сдвигает расписание.
+	// Задаётся хранилищем, значение из запроса не используется.
+	ScheduleDate string `json:"schedule_date,omitempty"`
+	// Time - необязательное время выполнения в формате "15:04".
+	Time string `json:"time,omitempty"`
+	// Timezone - часовой пояс задачи в формате IANA, например "Europe/Moscow"; пусто - пояс по умолчанию из TODO_TIMEZONE.
+	// В этом поясе вычисляются сегодняшний день, следующая дата и срок выполнения.
+	Timezone string `json:"timezone,omitempty"`
+	// Priority - приоритет задачи от 0 (без приоритета) до MaxPriority.
+	Priority int `json:"priority,string,omitempty"`
+	// Tags - теги задачи через запятую без пробелов, например "work,urgent".
+	Tags string `json:"tags,omitempty"`
+	// Snippet - фрагмент названия или комментария с выделенными тегами <mark> совпадениями; заполняется только в результатах поиска.
+	Snippet string `json:"snippet,omitempty"`
+	// Exceptions - даты-исключения в формате "20060102", на которые задача не назначается.
+	// Хранилище загружает их перед вычислением следующей даты; в JSON они не выводятся, список возвращает /api/task/skip.
+	Exceptions map[string]bool `json:"-"`
+	// rank - релевантность задачи поисковому запросу при сортировке SortRelevance; меньше - выше в результатах.
+	rank float64
+}
+
+// taskJSON - представление задачи в ответах API: поля Task и срок выполнения в формате RFC 3339.
+type taskJSON struct {
+	taskFields
+	Due string `json:"due,omitempty"`
+}
+
+// taskFields - Task без метода MarshalJSON.
+type taskFields Task
+
+// MarshalJSON добавляет к полям задачи срок выполнения due - дату и время задачи в её часовом поясе
+// в формате RFC 3339; у задачи без времени срок - начало дня.
+func (t Task) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.toJSON())
+}
+
+// UnmarshalJSON разбирает задачу из запроса API. Поля version, repeat_count и priority API возвращает строками,
+// но принимает их и строками, и числами JSON.
+func (t *Task) UnmarshalJSON(data []byte) error {
+	in := struct {
+		*taskFields
+		Version     jsonInt `json:"version"`
+		RepeatCount jsonInt `json:"repeat_count"`
+		Priority    jsonInt `json:"priority"`
+	}{(*taskFields)(t), jsonInt(t.Version), jsonInt(t.RepeatCount), jsonInt(t.Priority)}
+	err := json.Unmarshal(data, &in)
+	if err != nil {
+		return err
+	}
+	t.Version, t.RepeatCount, t.Priority = int(in.Version), int(in.RepeatCount), int(in.Priority)
+	return nil
+}
+
+// jsonInt - целое число, записанное в JSON числом или строкой.
+type jsonInt int
+
+// UnmarshalJSON разбирает число JSON или строку с целым числом; пустая строка означает 0, null не изменяет значение.
+func (n *jsonInt) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	text := string(data)
+	if strings.HasPrefix(text, `"`) {
+		err := json.Unmarshal(data, &text)
+		if err != nil {
+			return err
+		}
+		if text == "" {
+			*n = 0
+			return nil
+		}
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil {
+		return err
+	}
+	*n = jsonInt(value)
+	return nil
+}
+
+// toJSON возвращает представление задачи в ответах API.
+func (t Task) toJSON() taskJSON {
+	out := taskJSON{taskFields: taskFields(t)}
+	if due, err := t.Due(); err == nil {
+		out.Due = due.Format(time.RFC3339)
+	}
+	return out
+}
+
+// Due возвращает срок выполнения задачи: дату и время задачи (или начало дня) в её часовом поясе.
+//
+// Возвращает:
+// Срок выполнения и ошибку, если дата или время задачи неверны.
+func (t Task) Due() (time.Time, error) {
+	clock := t.Time
+	if clock == "" {
+		clock = "00:00"
+	}
+	return time.ParseInLocation("20060102 15:04", t.Date+" "+clock, t.Location())
+}
+
+// Location возвращает часовой пояс задачи; для пустого или неизвестного пояса - пояс по умолчанию.
+func (t Task) Location() *time.Location {
+	location, err := utils.Location(t.Timezone)
+	if err != nil {
+		location, _ = utils.Location("")
+	}
+	return location
+}
+
+// local переводит момент now в часовой пояс задачи.
+func (t Task) local(now time.Time) time.Time {
+	return now.In(t.Location())
+}
+
+// MaxPriority - наибольший приоритет задачи.
+const MaxPriority = 3
+
+// Ограничения на теги задачи.
+const (
+	// MaxTags - наибольшее число тегов задачи.
+	MaxTags = 10
+	// maxTagLength - наибольшая длина тега в символах.
+	maxTagLength = 32
+)
+
+// maxSkippedExceptions - сколько дат-исключений подряд можно пропустить при вычислении следующей даты.
+const maxSkippedExceptions = 1000
+
+// TaskStatus - статус задач, возвращаемых списком и поиском.
+type TaskStatus string
+
+const (
+	// StatusActive - только невыполненные задачи.
+	StatusActive TaskStatus = "active"
+	// StatusDone - только выполненные задачи.
+	StatusDone TaskStatus = "done"
+	// StatusAll - все задачи.
+	StatusAll TaskStatus = "all"
+)
+
+// ParseTaskStatus разбирает статус задач из параметра запроса. Пустая строка означает StatusActive.
+//
+// Параметры:
+// status - значение параметра status.
+//
+// Возвращает:
+// Статус задач или ошибку, если статус неизвестен.
+func ParseTaskStatus(status string) (TaskStatus, error) {
+	switch TaskStatus(status) {
+	case "", StatusActive:
+		return StatusActive, nil
+	case StatusDone, StatusAll:
+		return TaskStatus(status), nil
+	default:
+		return "", utils.NewError(utils.CodeInvalidRequest, "status", "неизвестный статус %s, ожидается active, done или all", status)
+	}
+}
+
+// matches проверяет, соответствует ли задача статусу.
+func (s TaskStatus) matches(t Task) bool {
+	switch s {
+	case StatusDone:
+		return t.CompletedAt != ""
+	case StatusAll:
+		return true
+	default:
+		return t.CompletedAt == ""
+	}
 }
 
 // CompleteRequest вычисляет следующую дату для указанной задачи на основе предоставленной даты и правила повторения.
@@ -21,20 +204,22 @@ type Task struct {
 // Если дата меньше текущей даты, есть два варианта:
 // - Если правило повторения не указано или оно пустое, используется текущая дата.
 // - Если указано правило повторения, необходимо вычислить и сохранить в базе данных следующую дату, которая больше текущей даты.
+// При этом в правиле RRULE с COUNT уменьшается число оставшихся повторений, а даты-исключения пропускаются.
 //
 // Параметры:
 // r: Структура Task, содержащая идентификатор, название, дату и правило повторения задачи.
+// now: Текущий момент; сегодняшний день определяется по нему в часовом поясе задачи.
 //
 // Возвращает:
 // Строка, представляющая следующую дату для задачи, или ошибку, если вычисление даты завершается с ошибкой.
 // Ошибка будет равна nil, если вычисление даты выполнено успешно.
-func (t *Task) CompleteRequest() (string, error) {
+func (t *Task) CompleteRequest(now time.Time) (string, error) {
 	var nextDate string
-	now := time.Now().Format("20060102")
-	timeNow, _ := time.Parse("20060102", now)
+	today := t.local(now).Format("20060102")
+	timeNow, _ := time.Parse("20060102", today)
 	// Если поле date не указано или содержит пустую строку, берётся сегодняшнее число.
 	if t.Date == "" || len(t.Date) == 0 {
-		t.Date = time.Now().Format("20060102")
+		t.Date = today
 		return t.Date, nil
 	}
 	nextDate = t.Date
@@ -42,24 +227,198 @@ func (t *Task) CompleteRequest() (string, error) {
 	if date, err := time.Parse("20060102", t.Date); err == nil && date.Before(timeNow) {
 		// если правило повторения не указано или равно пустой строке, подставляется сегодняшнее число;
 		if t.Repeat == "" || len(t.Repeat) == 0 {
-			nextDate = time.Now().Format("20060102")
+			nextDate = today
 			// при указанном правиле повторения вам нужно вычислить и записать в таблицу дату выполнения,
 			// которая будет больше сегодняшнего числа
 		} else if date.Equal(timeNow) {
 			return t.Date, nil
 		} else {
-			nextDate, err = utils.NextDate(time.Now(), t.Date, t.Repeat)
+			nextDate, t.Repeat, err = utils.AdvanceRepeat(t.local(now), t.Date, t.Repeat)
+			if err != nil {
+				return "", err
+			}
+			nextDate, t.Repeat, err = t.skipExceptions(now, nextDate, t.Repeat)
 			if err != nil {
 				return "", err
 			}
 		}
-	} else if err == nil && time.Now().Before(date) {
+	} else if err == nil && timeNow.Before(date) {
 		return t.Date, nil
 	}
 
 	return nextDate, nil
 }
 
+// DateToAdd вычисляет и записывает в задачу дату, с которой она будет сохранена при добавлении.
+// Дата, выпавшая на выходной или праздник, переносится по политике Roll, но не раньше текущей даты.
+//
+// Параметры:
+// now: Текущий момент.
+//
+// Возвращает:
+// Ошибку, если дата или правило повторения неверны, или nil.
+func (t *Task) DateToAdd(now time.Time) error {
+	nextDate, err := t.CompleteRequest(now)
+	if err != nil {
+		return err
+	}
+	t.Date, t.ScheduleDate = nextDate, ""
+	if t.Date != "" {
+		nextDate, err = t.CheckDate(now)
+		if err != nil {
+			return err
+		}
+		t.Date, err = utils.RollDate(nextDate, t.Roll, t.local(now).Format("20060102"))
+		if err != nil {
+			return err
+		}
+		if t.Date != nextDate {
+			t.ScheduleDate = nextDate
+		}
+	}
+	return nil
+}
+
+// scheduleDate возвращает дату задачи до переноса по политике Roll, от которой вычисляется следующее повторение.
+func (t Task) scheduleDate() string {
+	if t.ScheduleDate != "" {
+		return t.ScheduleDate
+	}
+	return t.Date
+}
+
+// nextDoneDate вычисляет дату следующего повторения задачи после её выполнения и правило повторения для неё.
+// Дата вычисляется от даты задачи до переноса по политике Roll (см. ScheduleDate).
+// Если для правила "d" дата следующего повторения совпадает с текущей датой, она сдвигается ещё на интервал повторения.
+// Даты-исключения задачи пропускаются.
+//
+// Возвращает:
+// Строку с датой следующего повторения в формате "20060102", правило повторения и ошибку;
+// utils.ErrRepeatEnded, если повторений больше нет и задачу нужно завершить как одноразовую.
+func (t Task) nextDoneDate(now time.Time) (string, string, error) {
+	const dateFormat = "20060102"
+	next, repeat, err := utils.AdvanceRepeat(t.local(now), t.scheduleDate(), t.Repeat)
+	if err != nil {
+		return "", "", err
+	}
+	if next == t.local(now).Format(dateFormat) && strings.HasPrefix(t.Repeat, "d ") {
+		date, err := time.Parse(dateFormat, next)
+		if err != nil {
+			return "", "", err
+		}
+		rptSlc := strings.Split(t.Repeat, " ")
+		subDays, err := strconv.Atoi(rptSlc[1])
+		if err != nil {
+			return "", "", err
+		}
+		next = date.AddDate(0, 0, subDays).Format(dateFormat)
+	}
+	return t.skipExceptions(now, next, repeat)
+}
+
+// skipExceptions пропускает даты-исключения задачи: пока date - исключение, вычисляет следующую дату по правилу repeat.
+//
+// Возвращает:
+// Первую дату, не являющуюся исключением, правило повторения для неё и ошибку.
+func (t Task) skipExceptions(now time.Time, date, repeat string) (string, string, error) {
+	for i := 0; t.Exceptions[date]; i++ {
+		if i == maxSkippedExceptions {
+			return "", "", utils.NewError(utils.CodeInvalidRepeat, "repeat", "слишком много дат-исключений подряд")
+		}
+		var err error
+		date, repeat, err = utils.AdvanceRepeat(t.local(now), date, repeat)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return date, repeat, nil
+}
+
+// checkDone проверяет, что задачу можно выполнить: она не выполнена, а её версия и дата совпадают с version и date,
+// которые видел клиент. Нулевой version и пустая date не проверяются.
+func (t Task) checkDone(version int, date string) error {
+	if version != 0 && version != t.Version {
+		return ErrConflict
+	}
+	if t.CompletedAt != "" {
+		return utils.Errorf("%w: задача уже выполнена", ErrConflict)
+	}
+	if date != "" && date != t.Date {
+		return utils.Errorf("%w: задача уже перенесена на %s", ErrConflict, t.Date)
+	}
+	return nil
+}
+
+// afterDone вычисляет состояние задачи после её выполнения: дату, правило повторения и число оставшихся повторений
+// следующего повторения либо признак completed, если задача одноразовая или её повторения закончились:
+// исчерпаны repeat_count или COUNT правила RRULE, или следующая дата позже repeat_until или UNTIL.
+// Следующая дата, выпавшая на выходной или праздник, переносится по политике Roll; дата до переноса
+// сохраняется в ScheduleDate, и от неё вычисляется повторение после следующего.
+//
+// Возвращает:
+// Задачу после выполнения, признак завершения задачи и ошибку, обёрнутую в ErrInvalidRepeat, если правило повторения неверно.
+func (t Task) afterDone(now time.Time) (Task, bool, error) {
+	if t.Repeat == "" || t.RepeatCount == 1 {
+		return t, true, nil
+	}
+	scheduled, repeat, err := t.nextDoneDate(now)
+	if errors.Is(err, utils.ErrRepeatEnded) {
+		return t, true, nil
+	}
+	var date string
+	if err == nil {
+		date, err = t.rollDone(now, scheduled)
+	}
+	if err != nil {
+		return t, false, utils.Errorf("%w: %v", ErrInvalidRepeat, err)
+	}
+	if t.RepeatUntil != "" && date > t.RepeatUntil {
+		return t, true, nil
+	}
+	next := t
+	next.Date, next.Repeat, next.ScheduleDate = date, repeat, ""
+	if date != scheduled {
+		next.ScheduleDate = scheduled
+	}
+	if next.RepeatCount > 0 {
+		next.RepeatCount--
+	}
+	return next, false, nil
+}
+
+// afterSkip возвращает задачу после пропуска её текущего повторения: дата переносится на следующее повторение,
+// как после выполнения, но пропущенное повторение не уменьшает число оставшихся повторений RepeatCount.
+// Пропуск не завершает задачу: если после текущего повторения других нет, возвращается ErrConflict.
+func (t Task) afterSkip(now time.Time) (Task, error) {
+	unlimited := t
+	unlimited.RepeatCount = 0
+	next, completed, err := unlimited.afterDone(now)
+	if err != nil {
+		return t, err
+	}
+	if completed {
+		return t, utils.Errorf("%w: нельзя пропустить последнее повторение задачи", ErrConflict)
+	}
+	next.RepeatCount = t.RepeatCount
+	return next, nil
+}
+
+// rollDone переносит дату следующего повторения по политике Roll. При переносе назад дата остаётся
+// позже и текущей даты задачи, и сегодняшнего дня, иначе переносится вперёд.
+func (t Task) rollDone(now time.Time, date string) (string, error) {
+	if t.Roll == utils.RollNone {
+		return date, nil
+	}
+	earliest, err := time.Parse("20060102", t.local(now).Format("20060102"))
+	if err != nil {
+		return "", err
+	}
+	if current, err := time.Parse("20060102", t.Date); err == nil && current.After(earliest) {
+		earliest = current
+	}
+	return utils.RollDate(date, t.Roll, earliest.AddDate(0, 0, 1).Format("20060102"))
+}
+
 // CheckRequest - это функция, которая проверяет входные данные для задачи.
 // Она проверяет поля ID, названия, даты и повторения в предоставленной задаче.
 //
@@ -73,52 +432,163 @@ func (t *Task) CompleteRequest() (string, error) {
 // - если поле ID не является числом, возвращается ошибка "не удается разобрать ID";
 // - если поле названия пустое или содержит только пробелы, возвращается ошибка "не указано название задачи";
 // - если поле даты не пустое и не соответствует формату "20060102", возвращается ошибка "неверный формат даты";
-// - если поле повторения не пустое и не соответствует определенным правилам, возвращается ошибка "неверный формат повторения".
+// - если поле повторения не пустое и не соответствует определенным правилам, возвращается ошибка "неверный формат повторения";
+// - если правило повторения в формате RRULE или "mw" неверно, возвращается ошибка с описанием неверного параметра;
+// - если условия окончания повторений repeat_until или repeat_count неверны, возвращается ошибка с их описанием;
+// - если политика переноса roll не "forward", "backward" или пустая строка, возвращается ошибка;
+// - если время не в формате "15:04" или часовой пояс неизвестен, возвращается ошибка;
+// - если приоритет не от 0 до MaxPriority или теги неверны, возвращается ошибка.
+//
+// Ошибки имеют тип *utils.Error с кодом и именем неверного поля.
 func (t Task) CheckTask() error {
 	if t.ID != "" || len(t.ID) != 0 {
 		_, err := strconv.Atoi(t.ID)
 		if err != nil {
-			err = fmt.Errorf("can not parse ID")
-			return err
+			return utils.NewError(utils.CodeInvalidID, "id", "не удаётся разобрать id")
 		}
 	}
 	if len(t.Title) == 0 || t.Title == "" || t.Title == " " {
-		return fmt.Errorf("не указано название задачи")
+		return utils.NewError(utils.CodeInvalidTitle, "title", "не указано название задачи")
 	}
 	if t.Date != "" {
 		if _, err := time.Parse("20060102", t.Date); err != nil {
-			return fmt.Errorf("неверный формат даты %s", t.Date)
+			return utils.NewError(utils.CodeInvalidDate, "date", "неверный формат даты %s", t.Date).WithDetail("format", "20060102")
 		}
 	}
+	if err := t.checkRepeatEnd(); err != nil {
+		return err
+	}
+	if err := utils.CheckRoll(t.Roll); err != nil {
+		return err
+	}
+	if t.Time != "" {
+		if _, err := time.Parse("15:04", t.Time); err != nil || len(t.Time) != 5 {
+			return utils.NewError(utils.CodeInvalidTime, "time", "неверный формат времени %s, ожидается ЧЧ:ММ", t.Time).WithDetail("format", "15:04")
+		}
+	}
+	if _, err := utils.Location(t.Timezone); err != nil {
+		return err
+	}
+	if t.Priority < 0 || t.Priority > MaxPriority {
+		return utils.NewError(utils.CodeInvalidPriority, "priority", "приоритет должен быть от 0 до %d", MaxPriority)
+	}
+	if err := t.checkTags(); err != nil {
+		return err
+	}
+	if err := t.checkRepeat(); err != nil {
+		return utils.NewError(utils.CodeInvalidRepeat, "repeat", "%w", err)
+	}
+	return nil
+}
+
+// checkRepeat проверяет формат правила повторения задачи.
+func (t Task) checkRepeat() error {
+	if utils.IsRRule(t.Repeat) {
+		return utils.CheckRRule(t.Repeat)
+	}
+	if strings.HasPrefix(t.Repeat, "mw ") {
+		return utils.CheckMonthWeekday(t.Repeat)
+	}
 	if len(t.Repeat) != 0 || t.Repeat != "" {
 		repeatSlc := strings.Split(t.Repeat, " ")
 		rule := repeatSlc[0]
-		if rule == "y" || rule == "d" || rule == "w" || rule == "m" {
-			if len(repeatSlc) > 3 || rule == "y" && len(repeatSlc) > 1 || rule == "d" && len(repeatSlc) == 1 || rule == "d" && len(repeatSlc) > 2 || rule == "w" && len(repeatSlc) != 2 {
-				return fmt.Errorf("неверный формат repeat")
+		if rule == "y" || rule == "d" || rule == "w" || rule == "m" || rule == "bd" {
+			if len(repeatSlc) > 3 || rule == "y" && len(repeatSlc) > 1 || rule == "d" && len(repeatSlc) == 1 || rule == "d" && len(repeatSlc) > 2 || rule == "w" && len(repeatSlc) != 2 || rule == "bd" && len(repeatSlc) != 2 {
+				return utils.Errorf("неверный формат repeat")
 			}
 		} else {
-			return fmt.Errorf("неверный формат repeat")
+			return utils.Errorf("неверный формат repeat")
+		}
+	}
+	return nil
+}
+
+// checkTags проверяет теги задачи: не больше MaxTags тегов через запятую, каждый - от 1 до maxTagLength
+// букв, цифр, знаков "_" и "-".
+func (t Task) checkTags() error {
+	if t.Tags == "" {
+		return nil
+	}
+	tags := t.TagList()
+	if len(tags) > MaxTags {
+		return utils.NewError(utils.CodeInvalidTags, "tags", "у задачи не может быть больше %d тегов", MaxTags)
+	}
+	for _, tag := range tags {
+		if !validTag(tag) {
+			return utils.NewError(utils.CodeInvalidTags, "tags", "неверный тег %q: допустимы буквы, цифры, _ и -", tag)
+		}
+	}
+	return nil
+}
+
+// validTag проверяет, что тег состоит из букв, цифр, знаков "_" и "-" и не длиннее maxTagLength символов.
+func validTag(tag string) bool {
+	if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+		return false
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
 		}
 	}
+	return true
+}
+
+// TagList возвращает теги задачи списком.
+func (t Task) TagList() []string {
+	if t.Tags == "" {
+		return nil
+	}
+	return strings.Split(t.Tags, ",")
+}
+
+// checkRepeatEnd проверяет условия окончания повторений: repeat_until должен быть датой в формате "20060102"
+// не раньше даты задачи, repeat_count - неотрицательным числом; оба поля допустимы только с правилом повторения.
+func (t Task) checkRepeatEnd() error {
+	if t.RepeatUntil != "" {
+		if t.Repeat == "" {
+			return utils.NewError(utils.CodeInvalidRepeatEnd, "repeat_until", "repeat_until указан без правила повторения")
+		}
+		if _, err := time.Parse("20060102", t.RepeatUntil); err != nil {
+			return utils.NewError(utils.CodeInvalidRepeatEnd, "repeat_until", "неверный формат repeat_until %s", t.RepeatUntil).
+				WithDetail("format", "20060102")
+		}
+		if t.Date != "" && t.RepeatUntil < t.Date {
+			return utils.NewError(utils.CodeInvalidRepeatEnd, "repeat_until", "repeat_until раньше даты задачи")
+		}
+	}
+	if t.RepeatCount < 0 {
+		return utils.NewError(utils.CodeInvalidRepeatEnd, "repeat_count", "repeat_count не может быть отрицательным")
+	}
+	if t.RepeatCount > 0 && t.Repeat == "" {
+		return utils.NewError(utils.CodeInvalidRepeatEnd, "repeat_count", "repeat_count указан без правила повторения")
+	}
 	return nil
 }
 
-func (t Task) CheckDate() (string, error) {
+// CheckDate проверяет, что дата задачи не раньше сегодняшнего дня в часовом поясе задачи.
+//
+// Параметры:
+// now: Текущий момент.
+//
+// Возвращает:
+// Дату задачи или ошибку, если дата неверна или уже прошла.
+func (t Task) CheckDate(now time.Time) (string, error) {
 	var date string
-	now, err := time.Parse("20060102", time.Now().Format("20060102"))
+	now, err := time.Parse("20060102", t.local(now).Format("20060102"))
 	if err != nil {
 		return "", err
 	}
 	tmpDate, err := time.Parse("20060102", t.Date)
 	if err != nil {
-		return "", err
+		return "", utils.NewError(utils.CodeInvalidDate, "date", "неверный формат даты %s", t.Date).WithDetail("format", "20060102")
 	}
-	if t.Date == time.Now().Format("20060102") || now.Before(tmpDate) {
+	if t.Date == now.Format("20060102") || now.Before(tmpDate) {
 		date = t.Date
 	}
-	if tmpDate.Before(time.Now()) && !now.Equal(tmpDate) {
-		return "", fmt.Errorf("date is less than today's date")
+	if tmpDate.Before(now) && !now.Equal(tmpDate) {
+		return "", utils.NewError(utils.CodeDateInPast, "date", "дата раньше сегодняшней").
+			WithDetail("today", now.Format("20060102"))
 	}
 	return date, nil
 }
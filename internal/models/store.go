@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go_final_project/internal/utils"
+)
+
+var (
+	// ErrNotFound возвращается, если задачи с указанным идентификатором у пользователя нет.
+	ErrNotFound = utils.NewError(utils.CodeNotFound, "id", "запись не найдена")
+	// ErrConflict возвращается, если задача была изменена другим запросом после того, как её прочитали.
+	ErrConflict = utils.NewError(utils.CodeConflict, "version", "задача изменена другим запросом")
+	// ErrInvalidRepeat возвращается, если по правилу повторения задачи нельзя вычислить следующую дату.
+	ErrInvalidRepeat = utils.NewError(utils.CodeInvalidRepeat, "repeat", "неверное правило повторения")
+	// ErrNotRepeating возвращается при попытке пропустить повторение задачи без правила повторения.
+	ErrNotRepeating = utils.NewError(utils.CodeNotRepeating, "repeat", "задача не повторяется")
+)
+
+// TaskStore - хранилище задач. Все методы, кроме UpdateDate и PurgeCompleted, работают только с задачами пользователя userID.
+type TaskStore interface {
+	CheckID(userID int64, id int) error
+	Insert(userID int64, task *Task) (int, error)
+	Update(userID int64, task *Task) error
+	UpdateDate(task *Task) error
+	Delete(userID int64, id int) error
+	GetTask(userID int64, id int) (*Task, error)
+	GetAll(userID int64, query TaskQuery) (TaskPage, error)
+	Search(userID int64, key string, query TaskQuery) (TaskPage, error)
+	Between(userID int64, from, to string) ([]Task, error)
+	Done(userID int64, id int, version int, date, note string) error
+	Undone(userID int64, id int) error
+	History(userID int64, id int) ([]Completion, error)
+	Skip(userID int64, id int, date string) error
+	Exceptions(userID int64, id int) ([]string, error)
+	DeleteException(userID int64, id int, date string) error
+	PurgeCompleted(before time.Time) (int64, error)
+}
+
+// AccountStore - хранилище учётных записей и связанных с аутентификацией данных:
+// отозванных токенов, счётчиков попыток входа и API-ключей.
+type AccountStore interface {
+	CreateUser(login, password string) (*User, error)
+	Authenticate(login, password string) (*User, error)
+
+	RevokeToken(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+
+	LoadLoginAttempts() ([]LoginAttempt, error)
+	SaveLoginAttempt(attempt LoginAttempt) error
+	DeleteLoginAttempt(key string) error
+
+	CreateAPIKey(userID int64, name, scope string) (*APIKey, string, error)
+	ListAPIKeys(userID int64) ([]APIKey, error)
+	DeleteAPIKey(userID, id int64) error
+	PruneAPIKeys(userID int64, before time.Time) (int64, error)
+	UseAPIKey(secret string) (*APIKey, error)
+}
+
+// Store объединяет хранилища, с которыми работают обработчики HTTP-запросов.
+type Store interface {
+	TaskStore
+	AccountStore
+}
+
+var (
+	_ Store = (*DBConnection)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
+
+// OpenStore открывает хранилище, выбранное по строке подключения dsn:
+// - "memory://" - хранилище в памяти, данные теряются при остановке;
+// - "postgres://..." или "postgresql://..." - PostgreSQL через драйвер pgDriver, который должен быть подключён в сборке;
+// - "sqlite://<путь>" или просто путь к файлу - SQLite.
+// Схему SQL-хранилища (*DBConnection) нужно привести к текущей версии вызовом Migrate.
+//
+// Параметры:
+// - dsn: Строка подключения.
+// - pgDriver: Имя драйвера database/sql для PostgreSQL.
+// - clock: Часы, по которым вычисляются даты задач и отметки времени.
+// - logger: Журнал.
+//
+// Возвращает:
+// - Хранилище, функцию закрытия подключения и ошибку, если открыть хранилище не удалось.
+func OpenStore(dsn, pgDriver string, clock utils.Clock, logger *zap.SugaredLogger) (Store, func() error, error) {
+	switch {
+	case dsn == "memory://" || dsn == ":memory:":
+		return NewMemoryStore(clock, logger), func() error { return nil }, nil
+	case strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://"):
+		return openSQL(pgDriver, dsn, Postgres, clock, logger)
+	default:
+		return openSQL("sqlite", sqliteDSN(strings.TrimPrefix(dsn, "sqlite://")), SQLite, clock, logger)
+	}
+}
+
+// sqliteDSN дополняет строку подключения SQLite параметрами, без которых транзакции чтения-изменения
+// (например, в Done) могут завершаться ошибкой SQLITE_BUSY: транзакция сразу берёт блокировку на запись,
+// а конкурирующее подключение ждёт её освобождения, а не завершается ошибкой.
+// Параметры, уже заданные в dsn, не переопределяются.
+func sqliteDSN(dsn string) string {
+	params := make([]string, 0, 2)
+	if !strings.Contains(dsn, "_txlock=") {
+		params = append(params, "_txlock=immediate")
+	}
+	if !strings.Contains(dsn, "busy_timeout") {
+		params = append(params, "_pragma=busy_timeout(5000)")
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + strings.Join(params, "&")
+}
+
+// openSQL открывает SQL-хранилище с указанным драйвером и диалектом.
+func openSQL(driver, dsn string, dialect Dialect, clock utils.Clock, logger *zap.SugaredLogger) (Store, func() error, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open %s: %w", dialect.Name, err)
+	}
+	return NewDialectConnection(db, dialect, clock, logger), db.Close, nil
+}
@@ -0,0 +1,257 @@
+package models
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"go_final_project/internal/utils"
+)
+
+// Ограничения на размер страницы списка задач.
+const (
+	// DefaultTaskLimit - размер страницы, если limit не указан.
+	DefaultTaskLimit = 50
+	// MaxTaskLimit - наибольший допустимый размер страницы.
+	MaxTaskLimit = 500
+)
+
+// TaskSort - поле, по которому сортируется список задач.
+type TaskSort string
+
+const (
+	// SortDate - по дате и времени задачи.
+	SortDate TaskSort = "date"
+	// SortTitle - по названию задачи.
+	SortTitle TaskSort = "title"
+	// SortCreated - по порядку создания задач.
+	SortCreated TaskSort = "created"
+	// SortPriority - по приоритету, задачи с одинаковым приоритетом - по дате и времени.
+	SortPriority TaskSort = "priority"
+	// SortRelevance - по релевантности поисковому запросу; без слов для поиска - как SortDate.
+	SortRelevance TaskSort = "relevance"
+)
+
+// sortColumns - столбцы таблицы scheduler, по которым упорядочивается список при каждом способе сортировки.
+// Последний столбец - id, поэтому порядок задач всегда однозначен и по нему можно строить курсор.
+var sortColumns = map[TaskSort][]string{
+	SortDate:      {"date", "due_time", "id"},
+	SortTitle:     {"title", "id"},
+	SortCreated:   {"id"},
+	SortPriority:  {"priority", "date", "due_time", "id"},
+	SortRelevance: {"rank", "id"},
+}
+
+// TaskQuery - параметры выборки списка задач: статус, размер страницы, сортировка и позиция страницы.
+type TaskQuery struct {
+	Status TaskStatus
+	Limit  int
+	Sort   TaskSort
+	Desc   bool
+	// after - ключ последней задачи предыдущей страницы; nil - первая страница.
+	after *sortKey
+	// ranked - у задач выборки вычислена релевантность rank, и сортировка SortRelevance упорядочивает по ней.
+	ranked bool
+}
+
+// TaskPage - страница списка задач. NextCursor пуст на последней странице,
+// Total - число всех задач, подходящих под запрос, без учёта страниц.
+type TaskPage struct {
+	Tasks      []Task `json:"tasks"`
+	NextCursor string `json:"next_cursor"`
+	Total      int    `json:"total"`
+}
+
+// sortKey - значения задачи, по которым она упорядочивается в списке. Курсор хранит ключ последней задачи страницы
+// вместе со способом сортировки, поэтому следующая страница продолжается с того же места,
+// даже если между запросами задачи добавлялись или удалялись.
+type sortKey struct {
+	Sort     TaskSort `json:"s"`
+	Desc     bool     `json:"d,omitempty"`
+	Date     string   `json:"dt,omitempty"`
+	Time     string   `json:"tm,omitempty"`
+	Title    string   `json:"t,omitempty"`
+	Priority int      `json:"p,omitempty"`
+	Rank     float64  `json:"r,omitempty"`
+	ID       int      `json:"id"`
+}
+
+// ParseTaskQuery разбирает параметры списка задач из запроса. Пустые значения заменяются значениями по умолчанию:
+// активные задачи, DefaultTaskLimit задач на странице, сортировка по дате по возрастанию.
+// Если указан cursor, а sort или order нет, они берутся из курсора.
+//
+// Параметры:
+// status - статус задач (active, done или all).
+// limit - размер страницы от 1 до MaxTaskLimit.
+// sort - поле сортировки (date, title, created, priority или relevance).
+// order - направление сортировки (asc или desc).
+// cursor - значение next_cursor из предыдущего ответа.
+//
+// Возвращает:
+// Параметры выборки или ошибку с кодом invalid_request и именем неверного параметра.
+func ParseTaskQuery(status, limit, sort, order, cursor string) (TaskQuery, error) {
+	query := TaskQuery{Limit: DefaultTaskLimit, Sort: SortDate}
+	var err error
+	query.Status, err = ParseTaskStatus(status)
+	if err != nil {
+		return query, err
+	}
+	if limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > MaxTaskLimit {
+			return query, utils.NewError(utils.CodeInvalidRequest, "limit", "limit должен быть числом от 1 до %d", MaxTaskLimit)
+		}
+	}
+	if cursor != "" {
+		query.after, err = decodeCursor(cursor)
+		if err != nil {
+			return query, err
+		}
+		query.Sort, query.Desc = query.after.Sort, query.after.Desc
+	}
+	if sort != "" {
+		if _, ok := sortColumns[TaskSort(sort)]; !ok {
+			return query, utils.NewError(utils.CodeInvalidRequest, "sort",
+				"неизвестная сортировка %s, ожидается date, title, created, priority или relevance", sort)
+		}
+		query.Sort = TaskSort(sort)
+	}
+	switch order {
+	case "":
+	case "asc", "desc":
+		query.Desc = order == "desc"
+	default:
+		return query, utils.NewError(utils.CodeInvalidRequest, "order", "неизвестный порядок %s, ожидается asc или desc", order)
+	}
+	if query.after != nil && (query.after.Sort != query.Sort || query.after.Desc != query.Desc) {
+		return query, utils.NewError(utils.CodeInvalidRequest, "cursor", "курсор получен для другой сортировки")
+	}
+	return query, nil
+}
+
+// decodeCursor разбирает курсор, закодированный encodeCursor.
+func decodeCursor(cursor string) (*sortKey, error) {
+	invalid := utils.NewError(utils.CodeInvalidRequest, "cursor", "неверный курсор")
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+	var key sortKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, invalid
+	}
+	if _, ok := sortColumns[key.Sort]; !ok {
+		return nil, invalid
+	}
+	return &key, nil
+}
+
+// encodeCursor кодирует ключ задачи в непрозрачную для клиента строку.
+func encodeCursor(key sortKey) string {
+	data, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// keyOf возвращает ключ сортировки задачи t для выборки q.
+func (q TaskQuery) keyOf(t Task) sortKey {
+	id, _ := strconv.Atoi(t.ID)
+	return sortKey{Sort: q.Sort, Desc: q.Desc, Date: t.Date, Time: t.Time, Title: t.Title, Priority: t.Priority, Rank: t.rank, ID: id}
+}
+
+// columns возвращает столбцы, по которым упорядочивается выборка q.
+func (q TaskQuery) columns() []string {
+	if q.Sort == SortRelevance && !q.ranked {
+		return sortColumns[SortDate]
+	}
+	return sortColumns[q.Sort]
+}
+
+// value возвращает значение ключа для столбца column из sortColumns.
+func (k sortKey) value(column string) any {
+	switch column {
+	case "rank":
+		return k.Rank
+	case "date":
+		return k.Date
+	case "due_time":
+		return k.Time
+	case "title":
+		return k.Title
+	case "priority":
+		return k.Priority
+	default:
+		return k.ID
+	}
+}
+
+// compare сравнивает ключи задач в порядке выборки q: отрицательное число, если a идёт раньше b.
+func (q TaskQuery) compare(a, b sortKey) int {
+	for _, column := range q.columns() {
+		var c int
+		switch v := a.value(column).(type) {
+		case string:
+			c = cmp.Compare(v, b.value(column).(string))
+		case int:
+			c = cmp.Compare(v, b.value(column).(int))
+		case float64:
+			c = cmp.Compare(v, b.value(column).(float64))
+		}
+		if c != 0 {
+			if q.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+// page собирает страницу из задач, упорядоченных и отобранных после курсора, и общего числа задач total.
+// tasks может содержать на одну задачу больше limit: по ней определяется, есть ли следующая страница.
+func (q TaskQuery) page(tasks []Task, total int) TaskPage {
+	page := TaskPage{Tasks: tasks, Total: total}
+	if len(tasks) > q.Limit {
+		page.Tasks = tasks[:q.Limit]
+		page.NextCursor = encodeCursor(q.keyOf(page.Tasks[q.Limit-1]))
+	}
+	if page.Tasks == nil {
+		page.Tasks = []Task{}
+	}
+	return page
+}
+
+// orderBy возвращает выражение ORDER BY для выборки q.
+func (q TaskQuery) orderBy() string {
+	columns := q.columns()
+	direction := " ASC"
+	if q.Desc {
+		direction = " DESC"
+	}
+	terms := make([]string, len(columns))
+	for i, column := range columns {
+		terms[i] = column + direction
+	}
+	return ` ORDER BY ` + strings.Join(terms, ", ")
+}
+
+// afterClause возвращает условие SQL, отбирающее задачи после курсора, которое дописывается к WHERE, и его аргументы.
+// Для первой страницы условие пустое.
+func (q TaskQuery) afterClause() (string, []any) {
+	if q.after == nil {
+		return ``, nil
+	}
+	columns := q.columns()
+	args := make([]any, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		args[i] = q.after.value(column)
+		placeholders[i] = "?"
+	}
+	op := " > "
+	if q.Desc {
+		op = " < "
+	}
+	return ` AND (` + strings.Join(columns, ", ") + `)` + op + `(` + strings.Join(placeholders, ", ") + `)`, args
+}
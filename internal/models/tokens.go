@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// RevokeToken добавляет идентификатор токена в список отозванных.
+// Заодно из списка удаляются записи о токенах, срок действия которых уже истёк.
+//
+// Параметры:
+// - jti: Уникальный идентификатор токена.
+// - expiresAt: Время истечения срока действия токена.
+//
+// Возвращает:
+// - Ошибку, если во время записи произошла ошибка, иначе nil.
+func (c *DBConnection) RevokeToken(jti string, expiresAt time.Time) error {
+	_, err := c.exec(`INSERT INTO revoked_tokens (jti, expires_at) VALUES (?, ?) ON CONFLICT (jti) DO NOTHING`,
+		jti, expiresAt.Unix())
+	if err != nil {
+		c.logger.Errorw("error revoking token", "error", err)
+		return err
+	}
+	_, err = c.exec(`DELETE FROM revoked_tokens WHERE expires_at < ?`, c.clock.Now().Unix())
+	if err != nil {
+		c.logger.Errorw("error purging revoked tokens", "error", err)
+		return err
+	}
+	c.logger.Infof("token %s revoked", jti)
+	return nil
+}
+
+// IsRevoked проверяет, был ли токен с указанным идентификатором отозван.
+//
+// Параметры:
+// - jti: Уникальный идентификатор токена.
+//
+// Возвращает:
+// - true, если токен отозван, и ошибку, если запрос к базе данных завершился неудачно.
+func (c *DBConnection) IsRevoked(jti string) (bool, error) {
+	var count int
+	err := c.queryRow(`SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?`, jti).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
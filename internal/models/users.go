@@ -0,0 +1,127 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"go_final_project/internal/utils"
+)
+
+// DefaultUserID - идентификатор пользователя по умолчанию.
+// Ему принадлежат задачи, созданные до появления учётных записей, и задачи, созданные при отключённой аутентификации.
+// Пользователь по умолчанию входит по общему паролю из переменной окружения TODO_PASSWORD.
+const DefaultUserID int64 = 1
+
+// Параметры хэширования паролей PBKDF2-HMAC-SHA256.
+const (
+	passwordIterations = 120000
+	passwordSaltLen    = 16
+	passwordKeyLen     = 32
+)
+
+var (
+	ErrUserExists         = utils.NewError(utils.CodeConflict, "login", "пользователь уже существует")
+	ErrInvalidCredentials = utils.NewError(utils.CodeUnauthorized, "", "неверное имя пользователя или пароль")
+)
+
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// CreateUser регистрирует нового пользователя. Пароль сохраняется в виде хэша PBKDF2-HMAC-SHA256 со случайной солью.
+//
+// Параметры:
+// - login: Имя пользователя.
+// - password: Пароль пользователя.
+//
+// Возвращает:
+// - Созданного пользователя и ошибку ErrUserExists, если имя уже занято, или другую ошибку при записи в базу данных.
+func (c *DBConnection) CreateUser(login, password string) (*User, error) {
+	hash, err := hashUserPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	// Занятость имени проверяет уникальный индекс, а не отдельный SELECT: при одновременной регистрации
+	// одного имени строку вставит только один запрос, остальные не получат идентификатор
+	var id int64
+	err = c.queryRow(`INSERT INTO users (login, password_hash, created_at) VALUES (?, ?, ?)
+	ON CONFLICT (login) DO NOTHING RETURNING id`, login, hash, c.clock.Now().Unix()).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserExists
+	}
+	if err != nil {
+		c.logger.Errorw("error inserting user", "error", err)
+		return nil, err
+	}
+	c.logger.Infof("user `%s` registered with ID: %d", login, id)
+	return &User{ID: id, Login: login}, nil
+}
+
+// Authenticate проверяет имя и пароль пользователя.
+// Пользователь по умолчанию не имеет собственного пароля и через этот метод войти не может.
+//
+// Параметры:
+// - login: Имя пользователя.
+// - password: Пароль пользователя.
+//
+// Возвращает:
+// - Пользователя и ErrInvalidCredentials, если пользователь не найден или пароль не совпадает.
+func (c *DBConnection) Authenticate(login, password string) (*User, error) {
+	user := User{}
+	var hash string
+	err := c.queryRow(`SELECT id, login, password_hash FROM users WHERE login = ?`, login).
+		Scan(&user.ID, &user.Login, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hash == "" || !checkUserPassword(hash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+// hashUserPassword вычисляет хэш пароля в формате "pbkdf2-sha256$<итерации>$<соль>$<хэш>".
+func hashUserPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2.Key([]byte(password), salt, passwordIterations, passwordKeyLen, sha256.New)
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		hex.EncodeToString(salt), hex.EncodeToString(key)), nil
+}
+
+// checkUserPassword сравнивает пароль с хэшем, полученным от hashUserPassword.
+func checkUserPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got := pbkdf2.Key([]byte(password), salt, iterations, len(want), sha256.New)
+	return hmac.Equal(got, want)
+}
@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Политики переноса даты задачи, выпавшей на выходной или праздник.
+const (
+	// RollNone - дата не переносится.
+	RollNone = ""
+	// RollForward - дата переносится на ближайший следующий рабочий день.
+	RollForward = "forward"
+	// RollBackward - дата переносится на ближайший предыдущий рабочий день.
+	RollBackward = "backward"
+)
+
+// businessDayRule - имя правила повторения через заданное число рабочих дней, например "bd 3".
+const businessDayRule = "bd"
+
+// maxRollDays - на сколько дней можно перенести дату в поисках рабочего дня.
+const maxRollDays = 366
+
+// calendar - праздничные дни, загруженные LoadHolidays, в формате "20060102".
+var calendar struct {
+	mu       sync.RWMutex
+	holidays map[string]bool
+}
+
+// LoadHolidays загружает календарь праздников из файла ICS (расширение .ics) или CSV (любое другое расширение)
+// и заменяет им ранее загруженный. В файле ICS праздником считается каждый день событий VEVENT от DTSTART
+// до DTEND, не включая его; повторяющиеся события (RRULE) не разворачиваются. В файле CSV дата берётся
+// из первого столбца в формате "20060102" или "2006-01-02"; строки, начинающиеся с "#", и заголовок пропускаются.
+//
+// Параметры:
+// path: Путь к файлу календаря.
+//
+// Возвращает:
+// Число загруженных праздничных дней и ошибку, если файл не читается или содержит неверную дату.
+func LoadHolidays(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	var holidays map[string]bool
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		holidays, err = parseICS(file)
+	} else {
+		holidays, err = parseHolidaysCSV(file)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	calendar.mu.Lock()
+	calendar.holidays = holidays
+	calendar.mu.Unlock()
+	return len(holidays), nil
+}
+
+// IsBusinessDay проверяет, является ли день рабочим: не субботой, не воскресеньем и не праздником из календаря.
+//
+// Параметры:
+// day: Проверяемый день.
+//
+// Возвращает:
+// true, если день рабочий.
+func IsBusinessDay(day time.Time) bool {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false
+	}
+	calendar.mu.RLock()
+	defer calendar.mu.RUnlock()
+	return !calendar.holidays[day.Format("20060102")]
+}
+
+// CheckRoll проверяет политику переноса даты: пустую строку, "forward" или "backward".
+//
+// Параметры:
+// roll: Политика переноса.
+//
+// Возвращает:
+// Ошибку, если политика неизвестна, иначе nil.
+func CheckRoll(roll string) error {
+	switch roll {
+	case RollNone, RollForward, RollBackward:
+		return nil
+	default:
+		return NewError(CodeInvalidRoll, "roll", "неверная политика переноса roll: %s, ожидается forward или backward", roll)
+	}
+}
+
+// RollDate переносит дату, выпавшую на выходной или праздник, на рабочий день по политике roll.
+// При переносе назад дата не может стать раньше earliest: в этом случае она переносится вперёд.
+//
+// Параметры:
+// date: Дата в формате "20060102".
+// roll: Политика переноса.
+// earliest: Самая ранняя допустимая дата в формате "20060102"; пустая строка - без ограничения.
+//
+// Возвращает:
+// Дату после переноса и ошибку, если дата неверна или рабочий день не найден.
+func RollDate(date, roll, earliest string) (string, error) {
+	if roll == RollNone {
+		return date, nil
+	}
+	day, err := time.Parse("20060102", date)
+	if err != nil {
+		return "", Errorf("неверный формат даты %s", date)
+	}
+	if roll == RollBackward {
+		back, err := nextBusinessDay(day, -1)
+		if err != nil {
+			return "", err
+		}
+		if earliest == "" || back.Format("20060102") >= earliest {
+			return back.Format("20060102"), nil
+		}
+	}
+	forward, err := nextBusinessDay(day, 1)
+	if err != nil {
+		return "", err
+	}
+	return forward.Format("20060102"), nil
+}
+
+// nextBusinessDay возвращает ближайший к day рабочий день, двигаясь с шагом step дней; сам day тоже проверяется.
+func nextBusinessDay(day time.Time, step int) (time.Time, error) {
+	for i := 0; i <= maxRollDays; i++ {
+		if IsBusinessDay(day) {
+			return day, nil
+		}
+		day = day.AddDate(0, 0, step)
+	}
+	return time.Time{}, Errorf("рабочий день не найден в пределах %d дней", maxRollDays)
+}
+
+// addBusinessDays прибавляет к дню n рабочих дней.
+func addBusinessDays(day time.Time, n int) (time.Time, error) {
+	for i := 0; i < n; i++ {
+		next, err := nextBusinessDay(day.AddDate(0, 0, 1), 1)
+		if err != nil {
+			return time.Time{}, err
+		}
+		day = next
+	}
+	return day, nil
+}
+
+// nextBusinessDayDate вычисляет для NextDate следующую дату правила "bd <N>": дата задачи сдвигается
+// на N рабочих дней, пока не станет позже текущей даты.
+func nextBusinessDayDate(now time.Time, dateStart time.Time, days []int) (string, error) {
+	if len(days) != 1 || days[0] < 1 {
+		return "", Errorf("неверный формат bd")
+	}
+	next, err := addBusinessDays(dateStart, days[0])
+	for err == nil && !next.After(now) {
+		next, err = addBusinessDays(next, days[0])
+	}
+	if err != nil {
+		return "", err
+	}
+	return next.Format("20060102"), nil
+}
+
+// parseICS читает праздничные дни из календаря iCalendar (RFC 5545).
+func parseICS(r io.Reader) (map[string]bool, error) {
+	holidays := make(map[string]bool)
+	var start, end time.Time
+	inEvent := false
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent, start, end = true, time.Time{}, time.Time{}
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if start.IsZero() {
+				return nil, fmt.Errorf("событие без DTSTART")
+			}
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+				holidays[day.Format("20060102")] = true
+			}
+			inEvent = false
+		case inEvent && (name == "DTSTART" || name == "DTEND"):
+			if len(value) < 8 {
+				return nil, fmt.Errorf("неверная дата %s: %s", name, value)
+			}
+			day, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return nil, fmt.Errorf("неверная дата %s: %s", name, value)
+			}
+			if name == "DTSTART" {
+				start = day
+			} else {
+				end = day
+			}
+		}
+	}
+	return holidays, nil
+}
+
+// unfoldICS читает строки календаря iCalendar, склеивая перенесённые строки, которые начинаются с пробела или табуляции.
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseHolidaysCSV читает праздничные дни из первого столбца файла CSV.
+func parseHolidaysCSV(r io.Reader) (map[string]bool, error) {
+	holidays := make(map[string]bool)
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := strings.TrimSpace(record[0])
+		if value == "" {
+			continue
+		}
+		day, err := time.Parse("20060102", value)
+		if err != nil {
+			day, err = time.Parse("2006-01-02", value)
+		}
+		if err != nil {
+			// первая строка может быть заголовком
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("строка %d: неверная дата %s", line, value)
+		}
+		holidays[day.Format("20060102")] = true
+	}
+	return holidays, nil
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Clock - источник текущего времени. Обработчики и хранилища получают его при создании,
+// чтобы поведение около полуночи и границ месяцев можно было воспроизвести с зафиксированным временем.
+type Clock interface {
+	// Now возвращает текущий момент.
+	Now() time.Time
+}
+
+// SystemClock - часы, возвращающие системное время.
+type SystemClock struct{}
+
+// Now возвращает системное время.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock - часы, всегда возвращающие один и тот же момент.
+type FixedClock struct {
+	At time.Time
+}
+
+// Now возвращает зафиксированный момент.
+func (c FixedClock) Now() time.Time {
+	return c.At
+}
+
+// CheckClock возвращает часы приложения. Если задана переменная окружения "TODO_FAKE_NOW",
+// время фиксируется на указанном моменте в формате "20060102", "20060102 15:04" или RFC 3339;
+// дата без смещения понимается в часовом поясе по умолчанию (TODO_TIMEZONE).
+// Иначе используются системные часы.
+//
+// Возвращает:
+// Часы и ошибку, если значение TODO_FAKE_NOW не разбирается.
+func CheckClock() (Clock, error) {
+	value := os.Getenv("TODO_FAKE_NOW")
+	if value == "" {
+		return SystemClock{}, nil
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return FixedClock{At: at}, nil
+	}
+	for _, layout := range []string{"20060102", "20060102 15:04"} {
+		if at, err := time.ParseInLocation(layout, value, defaultLocation()); err == nil {
+			return FixedClock{At: at}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid TODO_FAKE_NOW value %s, expected 20060102, 20060102 15:04 or RFC 3339", value)
+}
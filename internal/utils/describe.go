@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekdayGender - род названия дня недели: 0 - мужской, 1 - женский, 2 - средний.
+var weekdayGender = map[time.Weekday]int{
+	time.Monday:    0,
+	time.Tuesday:   0,
+	time.Wednesday: 1,
+	time.Thursday:  0,
+	time.Friday:    1,
+	time.Saturday:  1,
+	time.Sunday:    2,
+}
+
+// weekdayNames - названия дней недели в именительном падеже.
+var weekdayNames = map[time.Weekday]string{
+	time.Monday:    "понедельник",
+	time.Tuesday:   "вторник",
+	time.Wednesday: "среда",
+	time.Thursday:  "четверг",
+	time.Friday:    "пятница",
+	time.Saturday:  "суббота",
+	time.Sunday:    "воскресенье",
+}
+
+// weekdayPlural - названия дней недели для оборота "по понедельникам".
+var weekdayPlural = map[time.Weekday]string{
+	time.Monday:    "понедельникам",
+	time.Tuesday:   "вторникам",
+	time.Wednesday: "средам",
+	time.Thursday:  "четвергам",
+	time.Friday:    "пятницам",
+	time.Saturday:  "субботам",
+	time.Sunday:    "воскресеньям",
+}
+
+// monthNames - названия месяцев в предложном падеже для оборота "в феврале".
+var monthNames = [...]string{"", "январе", "феврале", "марте", "апреле", "мае", "июне",
+	"июле", "августе", "сентябре", "октябре", "ноябре", "декабре"}
+
+// ordinals - порядковые числительные для дней недели в месяце по родам.
+var ordinals = map[int][3]string{
+	1:  {"первый", "первая", "первое"},
+	2:  {"второй", "вторая", "второе"},
+	3:  {"третий", "третья", "третье"},
+	4:  {"четвёртый", "четвёртая", "четвёртое"},
+	5:  {"пятый", "пятая", "пятое"},
+	-1: {"последний", "последняя", "последнее"},
+	-2: {"предпоследний", "предпоследняя", "предпоследнее"},
+}
+
+// DescribeRepeat возвращает описание правила повторения на языке lang,
+// например "каждые 3 дня" или "ежемесячно, последний и предпоследний день месяца"
+// на русском языке и "every 3 days" на английском. Для неподдерживаемого языка описание составляется на DefaultLang.
+//
+// Параметры:
+// repeat: Строка правила повторения в любом поддерживаемом формате.
+// lang: Код языка описания.
+//
+// Возвращает:
+// Описание правила и ошибку, если правило неверно.
+func DescribeRepeat(repeat, lang string) (string, error) {
+	description, err := describeRepeat(repeat, lang)
+	if err != nil && ErrorCode(err) == "" {
+		return "", NewError(CodeInvalidRepeat, "repeat", "%w", err)
+	}
+	return description, err
+}
+
+// describeRepeat составляет для DescribeRepeat описание правила повторения.
+func describeRepeat(repeat, lang string) (string, error) {
+	if days, ok := strings.CutPrefix(repeat, businessDayRule+" "); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 || n > 400 {
+			return "", Errorf("неверный формат bd")
+		}
+		if lang == LangEn {
+			return describeBusinessDaysEn(n), nil
+		}
+		if n == 1 {
+			return "каждый рабочий день", nil
+		}
+		each := "каждые"
+		if n%10 == 1 && n%100 != 11 {
+			each = "каждый"
+		}
+		return fmt.Sprintf("%s %d %s", each, n, plural(n, "рабочий день", "рабочих дня", "рабочих дней")), nil
+	}
+	rule, err := ruleOf(repeat)
+	if err != nil {
+		return "", err
+	}
+	if lang == LangEn {
+		return rule.describeEn(), nil
+	}
+	return rule.describe(), nil
+}
+
+// ruleOf приводит правило повторения любого формата к разобранному правилу RRULE.
+func ruleOf(repeat string) (rrule, error) {
+	if IsRRule(repeat) {
+		return parseRRule(repeat)
+	}
+	rule := rrule{interval: 1, wkst: time.Monday}
+	repeatSlc := strings.Split(repeat, " ")
+	switch {
+	case repeatSlc[0] == monthWeekdayRule:
+		return parseMonthWeekday(repeat)
+	case repeat == "y":
+		rule.freq = "YEARLY"
+	case repeatSlc[0] == "d" && len(repeatSlc) == 2:
+		days, err := strconv.Atoi(repeatSlc[1])
+		if err != nil || days < 1 || days > 400 {
+			return rule, Errorf("неверный формат days")
+		}
+		rule.freq, rule.interval = "DAILY", days
+	case repeatSlc[0] == "w" && len(repeatSlc) == 2:
+		rule.freq = "WEEKLY"
+		for _, item := range strings.Split(repeatSlc[1], ",") {
+			day, err := strconv.Atoi(item)
+			if err != nil || day < 1 || day > 7 {
+				return rule, Errorf("неверный формат weekdays")
+			}
+			rule.byDay = append(rule.byDay, weekdayNum{day: time.Weekday(day % 7)})
+		}
+	case repeatSlc[0] == "m" && (len(repeatSlc) == 2 || len(repeatSlc) == 3):
+		rule.freq = "MONTHLY"
+		for _, item := range strings.Split(repeatSlc[1], ",") {
+			day, err := strconv.Atoi(item)
+			if err != nil || day == 0 || day > 31 || day < -2 {
+				return rule, Errorf("неверный формат monthDays")
+			}
+			rule.byMonthDay = append(rule.byMonthDay, day)
+		}
+		if len(repeatSlc) == 3 {
+			rule.byMonth = make(map[time.Month]bool)
+			for _, item := range strings.Split(repeatSlc[2], ",") {
+				month, err := strconv.Atoi(item)
+				if err != nil || month < 1 || month > 12 {
+					return rule, Errorf("неверный формат month")
+				}
+				rule.byMonth[time.Month(month)] = true
+			}
+		}
+	default:
+		return rule, Errorf("неверный формат repeat")
+	}
+	return rule, nil
+}
+
+// describe собирает описание правила из частоты, дней, месяцев и ограничений серии.
+func (r rrule) describe() string {
+	parts := []string{r.describeFreq()}
+	if days := r.describeDays(); days != "" {
+		parts = append(parts, days)
+	}
+	if len(r.byMonth) > 0 {
+		months := make([]string, 0, len(r.byMonth))
+		for month := time.January; month <= time.December; month++ {
+			if r.byMonth[month] {
+				months = append(months, monthNames[month])
+			}
+		}
+		parts = append(parts, "в "+joinAnd(months))
+	}
+	if r.count > 0 {
+		parts = append(parts, fmt.Sprintf("всего %d %s", r.count, plural(r.count, "раз", "раза", "раз")))
+	}
+	if !r.until.IsZero() {
+		parts = append(parts, "до "+r.until.Format("02.01.2006"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeFreq описывает частоту и интервал правила: "ежедневно", "каждые 2 недели".
+func (r rrule) describeFreq() string {
+	type unit struct {
+		every string
+		forms [3]string
+		each  string
+	}
+	units := map[string]unit{
+		"DAILY":   {"ежедневно", [3]string{"день", "дня", "дней"}, "каждый"},
+		"WEEKLY":  {"еженедельно", [3]string{"неделю", "недели", "недель"}, "каждую"},
+		"MONTHLY": {"ежемесячно", [3]string{"месяц", "месяца", "месяцев"}, "каждый"},
+		"YEARLY":  {"ежегодно", [3]string{"год", "года", "лет"}, "каждый"},
+	}
+	u := units[r.freq]
+	if r.interval == 1 {
+		return u.every
+	}
+	each := "каждые"
+	if r.interval%10 == 1 && r.interval%100 != 11 {
+		each = u.each
+	}
+	return fmt.Sprintf("%s %d %s", each, r.interval, plural(r.interval, u.forms[0], u.forms[1], u.forms[2]))
+}
+
+// describeDays описывает дни недели и дни месяца правила: "по понедельникам", "первая пятница", "1-е число".
+func (r rrule) describeDays() string {
+	var items []string
+	var weekly []string
+	for _, wd := range r.byDay {
+		if wd.n == 0 {
+			weekly = append(weekly, weekdayPlural[wd.day])
+			continue
+		}
+		items = append(items, ordinal(wd.n, weekdayGender[wd.day])+" "+weekdayNames[wd.day])
+	}
+	if len(weekly) > 0 {
+		items = append([]string{"по " + joinAnd(weekly)}, items...)
+	}
+	var first, last []string
+	monthDays := append([]int(nil), r.byMonthDay...)
+	sort.Ints(monthDays)
+	for _, day := range monthDays {
+		if day > 0 {
+			first = append(first, fmt.Sprintf("%d-е", day))
+		}
+	}
+	for i := len(monthDays) - 1; i >= 0; i-- {
+		if monthDays[i] < 0 {
+			last = append(last, ordinal(monthDays[i], 0))
+		}
+	}
+	if len(first) > 0 {
+		items = append(items, joinAnd(first)+" "+plural(len(first), "число", "числа", "числа"))
+	}
+	if len(last) > 0 {
+		items = append(items, joinAnd(last)+" день месяца")
+	}
+	return joinAnd(items)
+}
+
+// ordinal возвращает порядковое числительное n рода gender; отрицательные номера отсчитываются с конца.
+func ordinal(n, gender int) string {
+	if word, ok := ordinals[n]; ok {
+		return word[gender]
+	}
+	suffix := [3]string{"-й", "-я", "-е"}[gender]
+	if n < 0 {
+		return fmt.Sprintf("%d%s с конца", -n, suffix)
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// plural выбирает форму существительного для числа n: one - для 1, few - для 2-4, many - для остальных.
+func plural(n int, one, few, many string) string {
+	n %= 100
+	switch {
+	case n%10 == 1 && n != 11:
+		return one
+	case n%10 >= 2 && n%10 <= 4 && (n < 12 || n > 14):
+		return few
+	default:
+		return many
+	}
+}
+
+// joinAnd соединяет элементы списка через запятую, а последний - через "и".
+func joinAnd(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " и " + items[len(items)-1]
+}
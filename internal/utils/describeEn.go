@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ordinalsEn - английские порядковые числительные для дней недели в месяце.
+var ordinalsEn = map[int]string{
+	1:  "first",
+	2:  "second",
+	3:  "third",
+	4:  "fourth",
+	5:  "fifth",
+	-1: "last",
+	-2: "second to last",
+	-3: "third to last",
+	-4: "fourth to last",
+	-5: "fifth to last",
+}
+
+// describeBusinessDaysEn описывает правило "bd n" на английском языке.
+func describeBusinessDaysEn(n int) string {
+	if n == 1 {
+		return "every business day"
+	}
+	return fmt.Sprintf("every %d business days", n)
+}
+
+// describeEn собирает описание правила на английском языке так же, как describe.
+func (r rrule) describeEn() string {
+	parts := []string{r.describeFreqEn()}
+	if days := r.describeDaysEn(); days != "" {
+		parts = append(parts, days)
+	}
+	if len(r.byMonth) > 0 {
+		months := make([]string, 0, len(r.byMonth))
+		for month := time.January; month <= time.December; month++ {
+			if r.byMonth[month] {
+				months = append(months, month.String())
+			}
+		}
+		parts = append(parts, "in "+joinAndEn(months))
+	}
+	if r.count > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s in total", r.count, pluralEn(r.count, "time", "times")))
+	}
+	if !r.until.IsZero() {
+		parts = append(parts, "until "+r.until.Format("Jan 2, 2006"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeFreqEn описывает частоту и интервал правила: "daily", "every 2 weeks".
+func (r rrule) describeFreqEn() string {
+	units := map[string][3]string{
+		"DAILY":   {"daily", "day", "days"},
+		"WEEKLY":  {"weekly", "week", "weeks"},
+		"MONTHLY": {"monthly", "month", "months"},
+		"YEARLY":  {"yearly", "year", "years"},
+	}
+	u := units[r.freq]
+	if r.interval == 1 {
+		return u[0]
+	}
+	return fmt.Sprintf("every %d %s", r.interval, u[2])
+}
+
+// describeDaysEn описывает дни недели и дни месяца правила: "on Mondays", "the first Friday", "on the 1st".
+func (r rrule) describeDaysEn() string {
+	var items []string
+	var weekly []string
+	for _, wd := range r.byDay {
+		if wd.n == 0 {
+			weekly = append(weekly, wd.day.String()+"s")
+			continue
+		}
+		items = append(items, "the "+ordinalEn(wd.n)+" "+wd.day.String())
+	}
+	if len(weekly) > 0 {
+		items = append([]string{"on " + joinAndEn(weekly)}, items...)
+	}
+	var first, last []string
+	monthDays := append([]int(nil), r.byMonthDay...)
+	sort.Ints(monthDays)
+	for _, day := range monthDays {
+		if day > 0 {
+			first = append(first, dayOrdinalEn(day))
+		}
+	}
+	for i := len(monthDays) - 1; i >= 0; i-- {
+		if monthDays[i] < 0 {
+			last = append(last, ordinalEn(monthDays[i]))
+		}
+	}
+	if len(first) > 0 {
+		items = append(items, "on the "+joinAndEn(first))
+	}
+	if len(last) > 0 {
+		items = append(items, "the "+joinAndEn(last)+" "+pluralEn(len(last), "day", "days")+" of the month")
+	}
+	return joinAndEn(items)
+}
+
+// ordinalEn возвращает английское порядковое числительное для номера дня недели в месяце.
+func ordinalEn(n int) string {
+	if word, ok := ordinalsEn[n]; ok {
+		return word
+	}
+	if n < 0 {
+		return dayOrdinalEn(-n) + " to last"
+	}
+	return dayOrdinalEn(n)
+}
+
+// dayOrdinalEn возвращает число с английским суффиксом порядкового числительного: 1st, 2nd, 11th, 23rd.
+func dayOrdinalEn(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// pluralEn выбирает форму существительного для числа n.
+func pluralEn(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// joinAndEn соединяет элементы списка через запятую, а последний - через "and".
+func joinAndEn(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Коды ошибок API. Клиенты различают ошибки по коду, а не по тексту сообщения.
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeInvalidID        = "invalid_id"
+	CodeInvalidTitle     = "invalid_title"
+	CodeInvalidDate      = "invalid_date"
+	CodeDateInPast       = "date_in_past"
+	CodeInvalidRepeat    = "invalid_repeat"
+	CodeInvalidRepeatEnd = "invalid_repeat_end"
+	CodeRepeatEnded      = "repeat_ended"
+	CodeNotRepeating     = "not_repeating"
+	CodeInvalidRoll      = "invalid_roll"
+	CodeInvalidTime      = "invalid_time"
+	CodeInvalidTimezone  = "invalid_timezone"
+	CodeInvalidPriority  = "invalid_priority"
+	CodeInvalidTags      = "invalid_tags"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeTooManyRequests  = "too_many_requests"
+	CodeInternal         = "internal"
+)
+
+// Error - ошибка с машиночитаемым кодом. Field указывает поле запроса, к которому относится ошибка,
+// Details - необязательные подробности, например ожидаемый формат значения.
+// Ошибку можно оборачивать через fmt.Errorf("...: %w", err): код находится функцией ErrorCode.
+// Формат и аргументы сообщения сохраняются, чтобы Localize мог перевести сообщение на язык клиента.
+type Error struct {
+	Code    string
+	Field   string
+	Message string
+	Details map[string]string
+	format  string
+	args    []any
+	err     error
+}
+
+// NewError создаёт ошибку с кодом code для поля field. Сообщение форматируется как в fmt.Errorf,
+// поэтому глагол %w сохраняет исходную ошибку для errors.Is и errors.As.
+// Формат сообщения пишется на языке DefaultLang и служит ключом каталога переводов.
+//
+// Параметры:
+// code: Код ошибки.
+// field: Поле запроса или пустая строка, если ошибка не относится к отдельному полю.
+// format, args: Формат и аргументы сообщения.
+//
+// Возвращает:
+// Указатель на новую ошибку.
+func NewError(code, field, format string, args ...any) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: code, Field: field, Message: err.Error(), format: format, args: args, err: errors.Unwrap(err)}
+}
+
+// Errorf создаёт ошибку без кода с переводимым сообщением. Код такой ошибки берётся
+// из обёрнутой через %w ошибки, если она есть.
+//
+// Параметры:
+// format, args: Формат и аргументы сообщения.
+//
+// Возвращает:
+// Указатель на новую ошибку.
+func Errorf(format string, args ...any) *Error {
+	return NewError("", "", format, args...)
+}
+
+// Error возвращает сообщение об ошибке.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap возвращает исходную ошибку, переданную в NewError через %w.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// WithDetail добавляет к ошибке подробность key со значением value.
+//
+// Возвращает:
+// Ту же ошибку, чтобы вызовы можно было объединять в цепочку.
+func (e *Error) WithDetail(key, value string) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]string)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// Localize возвращает сообщение об ошибке на языке lang. Аргументы-ошибки переводятся так же,
+// сообщения ошибок других типов выводятся без перевода.
+//
+// Параметры:
+// lang: Код языка, например LangEn.
+//
+// Возвращает:
+// Переведённое сообщение.
+func (e *Error) Localize(lang string) string {
+	args := make([]any, len(e.args))
+	for i, arg := range e.args {
+		if err, ok := arg.(error); ok {
+			arg = Localize(err, lang)
+		}
+		args[i] = arg
+	}
+	return fmt.Sprintf(strings.ReplaceAll(Translate(lang, e.format), "%w", "%v"), args...)
+}
+
+// Localize возвращает сообщение ошибки err на языке lang, если это *Error, иначе err.Error().
+//
+// Параметры:
+// err: Ошибка.
+// lang: Код языка.
+//
+// Возвращает:
+// Сообщение об ошибке.
+func Localize(err error, lang string) string {
+	if e, ok := err.(*Error); ok {
+		return e.Localize(lang)
+	}
+	return err.Error()
+}
+
+// AsError возвращает первую ошибку с кодом в цепочке err или nil, если такой нет.
+//
+// Параметры:
+// err: Ошибка.
+//
+// Возвращает:
+// Ошибку с кодом.
+func AsError(err error) *Error {
+	for err != nil {
+		if e, ok := err.(*Error); ok && e.Code != "" {
+			return e
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
+
+// ErrorCode возвращает код ошибки err или пустую строку, если в цепочке err нет ошибки с кодом.
+//
+// Параметры:
+// err: Ошибка.
+//
+// Возвращает:
+// Код ошибки.
+func ErrorCode(err error) string {
+	if e := AsError(err); e != nil {
+		return e.Code
+	}
+	return ""
+}
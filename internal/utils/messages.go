@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Языки сообщений API.
+const (
+	LangRu = "ru"
+	LangEn = "en"
+)
+
+// DefaultLang - язык, на котором написаны исходные сообщения; он используется, если клиент не выбрал поддерживаемый язык.
+const DefaultLang = LangRu
+
+// MsgInternal - сообщение, которое клиент получает вместо текста внутренней ошибки сервера.
+const MsgInternal = "внутренняя ошибка сервера"
+
+// messages - каталог переводов: для каждого языка, кроме DefaultLang, исходный формат сообщения
+// сопоставляется с переведённым. Переведённый формат должен содержать те же глаголы в том же порядке.
+var messages = map[string]map[string]string{
+	LangEn: {
+		MsgInternal: "internal server error",
+
+		// Проверка задачи
+		"не удаётся разобрать id":                                             "can not parse id",
+		"не указан id":                                                        "id is empty",
+		"не указано название задачи":                                          "task title is empty",
+		"неверный формат даты %s":                                             "invalid date format %s",
+		"неверный формат текущей даты":                                        "invalid current date format",
+		"дата раньше сегодняшней":                                             "date is less than today's date",
+		"неверный формат времени %s, ожидается ЧЧ:ММ":                         "invalid time format %s, expected HH:MM",
+		"неизвестный часовой пояс %s":                                         "unknown time zone %s",
+		"неверная политика переноса roll: %s, ожидается forward или backward": "invalid roll policy %s, expected forward or backward",
+		"repeat_until указан без правила повторения":                          "repeat_until is set without a repeat rule",
+		"неверный формат repeat_until %s":                                     "invalid repeat_until format %s",
+		"repeat_until раньше даты задачи":                                     "repeat_until is before the task date",
+		"repeat_count не может быть отрицательным":                            "repeat_count can not be negative",
+		"repeat_count указан без правила повторения":                          "repeat_count is set without a repeat rule",
+		"неизвестный статус %s, ожидается active, done или all":               "unknown status %s, expected active, done or all",
+		"у задачи не может быть больше %d тегов":                              "a task can not have more than %d tags",
+		"неверный тег %q: допустимы буквы, цифры, _ и -":                      "invalid tag %q: letters, digits, _ and - are allowed",
+		"приоритет должен быть от 0 до %d":                                    "priority must be from 0 to %d",
+
+		// Правила повторения
+		"неверный формат repeat":                                                  "invalid repeat format",
+		"неверный формат days":                                                    "invalid days format",
+		"неверный формат days (d>400)":                                            "invalid days format (d>400)",
+		"неверный формат weekdays":                                                "invalid weekdays format",
+		"неверный формат weekdays: %s":                                            "invalid weekdays format: %s",
+		"неверный формат monthDays":                                               "invalid monthDays format",
+		"неверный формат month":                                                   "invalid month format",
+		"неверный формат bd":                                                      "invalid bd format",
+		"неверный формат mw: %s":                                                  "invalid mw format: %s",
+		"неверный номер дня недели в месяце: %s":                                  "invalid weekday number in month: %s",
+		"полученная дата меньше текущей даты":                                     "computed date is less than the current date",
+		"неверное правило повторения":                                             "invalid repeat rule",
+		"повторения задачи закончились":                                           "task repetitions have ended",
+		"правило повторения не задано":                                            "repeat rule is empty",
+		"правило повторения не даёт дат в ближайшие %d лет":                       "repeat rule yields no dates in the next %d years",
+		"рабочий день не найден в пределах %d дней":                               "no business day found within %d days",
+		"слишком много дат-исключений подряд":                                     "too many consecutive skip dates",
+		"число дат должно быть от 1 до %d":                                        "count must be from 1 to %d",
+		"правило RRULE должно начинаться с %s":                                    "RRULE must start with %s",
+		"правило RRULE длиннее %d символов":                                       "RRULE is longer than %d characters",
+		"неверный формат RRULE: %s":                                               "invalid RRULE format: %s",
+		"параметр RRULE %s указан дважды":                                         "RRULE parameter %s is set twice",
+		"неподдерживаемое значение FREQ: %s":                                      "unsupported FREQ value: %s",
+		"неверный формат UNTIL: %s":                                               "invalid UNTIL format: %s",
+		"неверный формат WKST: %s":                                                "invalid WKST format: %s",
+		"неверный формат BYMONTHDAY: %s":                                          "invalid BYMONTHDAY format: %s",
+		"неверный формат BYDAY: %s":                                               "invalid BYDAY format: %s",
+		"неверный формат %s: %s":                                                  "invalid %s format: %s",
+		"неподдерживаемый параметр RRULE: %s":                                     "unsupported RRULE parameter: %s",
+		"в правиле RRULE не указан FREQ":                                          "RRULE has no FREQ",
+		"в правиле RRULE нельзя указывать одновременно COUNT и UNTIL":             "RRULE can not have both COUNT and UNTIL",
+		"порядковый номер в BYDAY допустим только для FREQ=MONTHLY и FREQ=YEARLY": "BYDAY ordinals are allowed only with FREQ=MONTHLY and FREQ=YEARLY",
+		"порядковый номер дня недели в месяце должен быть от -5 до 5":             "weekday ordinal in month must be from -5 to 5",
+
+		// Хранилище
+		"запись не найдена":                                 "no such id",
+		"задача %d: %w":                                     "task %d: %w",
+		"задача изменена другим запросом":                   "task was modified concurrently",
+		"%w: задача уже выполнена":                          "%w: task is already completed",
+		"%w: нельзя пропустить последнее повторение задачи": "%w: cannot skip the last occurrence of the task",
+		"%w: задача уже перенесена на %s":                   "%w: task is already moved to %s",
+		"%w: задача не выполнена":                           "%w: task is not completed",
+		"задача не повторяется":                             "task does not repeat",
+		"нет даты-исключения %s у задачи %d: %w":            "no skip date %s for task %d: %w",
+		"пользователь уже существует":                       "user already exists",
+		"неверное имя пользователя или пароль":              "login or password is incorrect",
+		"неверный API-ключ":                                 "invalid api key",
+
+		// Запросы
+		"не удаётся разобрать тело запроса":                "can not parse request body",
+		"пустой запрос":                                    "request is empty",
+		"метод запроса должен быть POST":                   "request method must be POST",
+		"не указаны date или repeat":                       "date or repeat is empty",
+		"неверный формат now: %s":                          "invalid now format: %s",
+		"неверный формат from: %s":                         "invalid from format: %s",
+		"неверное значение count: %s":                      "invalid count: %s",
+		"не указана дата":                                  "date is required",
+		"не удаётся разобрать version":                     "can not parse version",
+		"слишком длинная заметка":                          "note is too long",
+		"не удаётся разобрать unused_days":                 "can not parse unused_days",
+		"область действия должна быть read или read-write": "scope must be read or read-write",
+
+		// Списки задач
+		"limit должен быть числом от 1 до %d":                                               "limit must be a number from 1 to %d",
+		"неизвестная сортировка %s, ожидается date, title, created, priority или relevance": "unknown sort %s, expected date, title, created, priority or relevance",
+		"неизвестный порядок %s, ожидается asc или desc":                                    "unknown order %s, expected asc or desc",
+		"курсор получен для другой сортировки":                                              "cursor was issued for another sort order",
+		"не указано значение условия %s":                                                    "condition %s has no value",
+		"условие %s не поддерживает оператор %s":                                            "condition %s does not support operator %s",
+		"неверная дата %s в условии %s":                                                     "invalid date %s in condition %s",
+		"неверное значение %s в условии %s, ожидается true или false":                       "invalid value %s in condition %s, expected true or false",
+		"неверное значение %s в условии %s":                                                 "invalid value %s in condition %s",
+		"days должен быть числом от 1 до %d":                                                "days must be a number from 1 to %d",
+		"не указан параметр %s":                                                             "parameter %s is required",
+		"to не может быть раньше from":                                                      "to can not be earlier than from",
+		"диапазон календаря не может быть длиннее %d дней":                                  "calendar range can not be longer than %d days",
+		"неверный курсор":                                                                   "invalid cursor",
+
+		// Аутентификация
+		"требуется аутентификация":                              "authentication required",
+		"аутентификация отключена":                              "authentication is disabled",
+		"слишком много попыток входа":                           "too many signin attempts",
+		"неверный пароль":                                       "password is incorrect",
+		"имя пользователя должно содержать от 1 до 64 символов": "login must be from 1 to 64 characters",
+		"пароль должен содержать не меньше 8 символов":          "password must be at least 8 characters",
+		"не указан refresh_token":                               "refresh_token is empty",
+		"срок действия токена истёк":                            "token expired",
+		"неверный токен":                                        "invalid token",
+		"неверные данные токена":                                "invalid token claims",
+		"ожидается токен %s":                                    "%s token expected",
+		"токен выдан для другого пароля":                        "token was issued for another password",
+		"у токена нет идентификатора":                           "token has no id",
+		"токен отозван":                                         "token revoked",
+		"у токена нет срока действия":                           "token has no expiration time",
+		"API-ключ только для чтения":                            "api key is read-only",
+		"API-ключом нельзя управлять API-ключами":               "api keys can not manage api keys",
+	},
+}
+
+// IsLanguage проверяет, поддерживается ли язык lang.
+//
+// Параметры:
+// lang: Код языка.
+//
+// Возвращает:
+// true, если для языка есть сообщения.
+func IsLanguage(lang string) bool {
+	_, ok := messages[lang]
+	return ok || lang == DefaultLang
+}
+
+// Translate переводит формат сообщения на язык lang. Если перевода нет, возвращается исходный формат.
+//
+// Параметры:
+// lang: Код языка.
+// format: Исходный формат сообщения на языке DefaultLang.
+//
+// Возвращает:
+// Переведённый формат.
+func Translate(lang, format string) string {
+	if translated, ok := messages[lang][format]; ok {
+		return translated
+	}
+	return format
+}
+
+// ParseAcceptLanguage выбирает из заголовка Accept-Language поддерживаемый язык с наибольшим весом q.
+// Региональные варианты, например "en-US", сводятся к основному языку.
+//
+// Параметры:
+// header: Значение заголовка Accept-Language.
+//
+// Возвращает:
+// Код языка или DefaultLang, если ни один язык из заголовка не поддерживается.
+func ParseAcceptLanguage(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, item := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if !IsLanguage(lang) {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang: lang, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLang
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].lang
+}
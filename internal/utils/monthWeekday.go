@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// monthWeekdayRule - имя правила повторения по дням недели месяца, например "mw 1:1,-1:5 1,7".
+const monthWeekdayRule = "mw"
+
+// CheckMonthWeekday проверяет правило повторения "mw <номер>:<день недели>,... [месяцы]".
+// Номер - порядковый номер дня недели в месяце от 1 до 5 или от -1 до -5 (-1 - последний),
+// день недели - от 1 (понедельник) до 7 (воскресенье), месяцы - необязательный список от 1 до 12.
+//
+// Параметры:
+// repeat: Строка правила повторения.
+//
+// Возвращает:
+// Ошибку, если правило неверно, иначе nil.
+func CheckMonthWeekday(repeat string) error {
+	_, err := parseMonthWeekday(repeat)
+	return err
+}
+
+// nextMonthWeekdayDate вычисляет для NextDate следующую дату правила "mw", которая больше и текущей даты, и даты задачи.
+func nextMonthWeekdayDate(now time.Time, date string, repeat string) (string, error) {
+	rule, err := parseMonthWeekday(repeat)
+	if err != nil {
+		return "", err
+	}
+	now, err = time.Parse("20060102", now.Format("20060102"))
+	if err != nil {
+		return "", Errorf("неверный формат текущей даты")
+	}
+	start, err := time.Parse("20060102", date)
+	if err != nil {
+		return "", Errorf("неверный формат даты %s", date)
+	}
+	after := now
+	if start.After(now) {
+		after = start
+	}
+	next, _, err := rule.next(start, after)
+	if err != nil {
+		return "", err
+	}
+	return next.Format("20060102"), nil
+}
+
+// parseMonthWeekday разбирает правило "mw" в эквивалентное правило FREQ=MONTHLY с BYDAY и BYMONTH.
+func parseMonthWeekday(repeat string) (rrule, error) {
+	rule := rrule{freq: "MONTHLY", interval: 1, wkst: time.Monday}
+	repeatSlc := strings.Split(repeat, " ")
+	if repeatSlc[0] != monthWeekdayRule || len(repeatSlc) < 2 || len(repeatSlc) > 3 {
+		return rule, Errorf("неверный формат repeat")
+	}
+	for _, item := range strings.Split(repeatSlc[1], ",") {
+		numStr, dayStr, ok := strings.Cut(item, ":")
+		if !ok {
+			return rule, Errorf("неверный формат mw: %s", item)
+		}
+		n, err := strconv.Atoi(numStr)
+		if err != nil || n == 0 || n > 5 || n < -5 {
+			return rule, Errorf("неверный номер дня недели в месяце: %s", item)
+		}
+		day, err := strconv.Atoi(dayStr)
+		if err != nil || day < 1 || day > 7 {
+			return rule, Errorf("неверный формат weekdays: %s", item)
+		}
+		rule.byDay = append(rule.byDay, weekdayNum{n: n, day: time.Weekday(day % 7)})
+	}
+	if len(repeatSlc) == 3 {
+		rule.byMonth = make(map[time.Month]bool)
+		for _, item := range strings.Split(repeatSlc[2], ",") {
+			month, err := strconv.Atoi(item)
+			if err != nil || month < 1 || month > 12 {
+				return rule, Errorf("неверный формат month")
+			}
+			rule.byMonth[time.Month(month)] = true
+		}
+	}
+	return rule, nil
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxOccurrences - наибольшее число дат, которое возвращает Occurrences.
+const MaxOccurrences = 100
+
+// Occurrences вычисляет до count ближайших дат повторения задачи тем же способом, что и NextDate:
+// первая дата совпадает с результатом NextDate(from, date, repeat), каждая следующая вычисляется от предыдущей.
+// Если повторения по COUNT или UNTIL правила RRULE заканчиваются раньше или следующую дату вычислить
+// не удаётся, список получается короче; ошибка возвращается, только если не вычислена ни одна дата.
+//
+// Параметры:
+// from: Дата, после которой ищутся повторения.
+// date: Дата задачи в формате "20060102".
+// repeat: Строка правила повторения.
+// count: Число дат, от 1 до MaxOccurrences.
+//
+// Возвращает:
+// Список дат в формате "20060102" и ошибку, если правило или дата неверны.
+func Occurrences(from time.Time, date string, repeat string, count int) ([]string, error) {
+	if repeat == "" {
+		return nil, NewError(CodeInvalidRepeat, "repeat", "правило повторения не задано")
+	}
+	if count < 1 || count > MaxOccurrences {
+		return nil, NewError(CodeInvalidRequest, "count", "число дат должно быть от 1 до %d", MaxOccurrences)
+	}
+	dates := make([]string, 0, count)
+	now := from
+	for len(dates) < count {
+		next, rule, err := AdvanceRepeat(now, date, repeat)
+		if err == nil && len(dates) > 0 && next <= date {
+			// правило "d 1" для даты, совпадающей с текущей, возвращает её же
+			day, _ := time.Parse("20060102", date)
+			next, rule, err = AdvanceRepeat(day.AddDate(0, 0, 1), date, repeat)
+		}
+		if errors.Is(err, ErrRepeatEnded) || err != nil && len(dates) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		dates = append(dates, next)
+		date, repeat = next, rule
+		now, err = time.Parse("20060102", next)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dates, nil
+}
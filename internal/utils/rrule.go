@@ -0,0 +1,386 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rrulePrefix - префикс правила повторения в формате RFC 5545.
+const rrulePrefix = "RRULE:"
+
+// maxRRuleLength - максимальная длина правила RRULE.
+const maxRRuleLength = 512
+
+// rruleSearchYears - на сколько лет вперёд ищется следующая дата правила RRULE.
+// Ограничение защищает от правил, которые никогда не дают дат, например BYMONTH=2;BYMONTHDAY=30.
+const rruleSearchYears = 100
+
+// ErrRepeatEnded возвращается, если у правила повторения больше нет дат: исчерпан COUNT или пройдена дата UNTIL.
+var ErrRepeatEnded = NewError(CodeRepeatEnded, "repeat", "повторения задачи закончились")
+
+// rruleWeekdays сопоставляет дни недели RFC 5545 дням недели time.Weekday.
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// rrule - разобранное правило повторения RFC 5545.
+type rrule struct {
+	freq       string
+	interval   int
+	byDay      []weekdayNum
+	byMonthDay []int
+	byMonth    map[time.Month]bool
+	count      int
+	until      time.Time
+	wkst       time.Weekday
+}
+
+// weekdayNum - элемент BYDAY: день недели и необязательный порядковый номер (0 - любой, -1 - последний).
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+// IsRRule проверяет, задано ли правило повторения в формате RFC 5545 (начинается с "RRULE:").
+//
+// Параметры:
+// repeat: Строка правила повторения.
+//
+// Возвращает:
+// true, если правило задано в формате RRULE.
+func IsRRule(repeat string) bool {
+	return len(repeat) >= len(rrulePrefix) && strings.EqualFold(repeat[:len(rrulePrefix)], rrulePrefix)
+}
+
+// CheckRRule проверяет правило повторения в формате RFC 5545.
+// Поддерживаются FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, BYDAY с порядковыми номерами,
+// BYMONTHDAY, BYMONTH, COUNT, UNTIL и WKST.
+//
+// Параметры:
+// repeat: Строка правила повторения, начинающаяся с "RRULE:".
+//
+// Возвращает:
+// Ошибку, если правило неверно или использует неподдерживаемые параметры, иначе nil.
+func CheckRRule(repeat string) error {
+	_, err := parseRRule(repeat)
+	return err
+}
+
+// AdvanceRepeat вычисляет следующую дату задачи так же, как NextDate, и правило повторения,
+// которое нужно сохранить вместе с новой датой. Для правил RRULE с COUNT в правиле остаётся число
+// ещё не наступивших повторений, считая новую дату; остальные правила возвращаются без изменений.
+//
+// Параметры:
+// now: Текущая дата и время.
+// date: Текущая дата задачи в формате "20060102".
+// repeat: Строка правила повторения.
+//
+// Возвращает:
+// Следующую дату, правило повторения для неё и ошибку; ErrRepeatEnded, если повторений больше нет.
+func AdvanceRepeat(now time.Time, date string, repeat string) (string, string, error) {
+	if !IsRRule(repeat) {
+		next, err := NextDate(now, date, repeat)
+		return next, repeat, err
+	}
+	rule, start, after, err := prepareRRule(now, date, repeat)
+	if err != nil {
+		return "", "", repeatError(date, err)
+	}
+	next, index, err := rule.next(start, after)
+	if err != nil {
+		return "", "", repeatError(date, err)
+	}
+	if rule.count > 0 {
+		repeat = withCount(repeat, rule.count-index)
+	}
+	return next.Format("20060102"), repeat, nil
+}
+
+// nextRRuleDate вычисляет для NextDate следующую дату правила RRULE, которая больше и текущей даты, и даты задачи.
+// Дата задачи считается первым повторением (DTSTART).
+func nextRRuleDate(now time.Time, date string, repeat string) (string, error) {
+	rule, start, after, err := prepareRRule(now, date, repeat)
+	if err != nil {
+		return "", err
+	}
+	next, _, err := rule.next(start, after)
+	if err != nil {
+		return "", err
+	}
+	return next.Format("20060102"), nil
+}
+
+// prepareRRule разбирает правило и даты и возвращает правило, дату первого повторения
+// и момент, после которого нужно искать следующую дату.
+func prepareRRule(now time.Time, date string, repeat string) (rrule, time.Time, time.Time, error) {
+	rule, err := parseRRule(repeat)
+	if err != nil {
+		return rrule{}, time.Time{}, time.Time{}, err
+	}
+	now, err = time.Parse("20060102", now.Format("20060102"))
+	if err != nil {
+		return rrule{}, time.Time{}, time.Time{}, Errorf("неверный формат текущей даты")
+	}
+	start, err := time.Parse("20060102", date)
+	if err != nil {
+		return rrule{}, time.Time{}, time.Time{}, Errorf("неверный формат даты %s", date)
+	}
+	after := now
+	if start.After(now) {
+		after = start
+	}
+	return rule, start, after, nil
+}
+
+// parseRRule разбирает правило повторения в формате RFC 5545.
+func parseRRule(repeat string) (rrule, error) {
+	rule := rrule{interval: 1, wkst: time.Monday}
+	if !IsRRule(repeat) {
+		return rule, Errorf("правило RRULE должно начинаться с %s", rrulePrefix)
+	}
+	if len(repeat) > maxRRuleLength {
+		return rule, Errorf("правило RRULE длиннее %d символов", maxRRuleLength)
+	}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(repeat[len(rrulePrefix):], ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key, value = strings.ToUpper(strings.TrimSpace(key)), strings.ToUpper(strings.TrimSpace(value))
+		if !ok || key == "" || value == "" {
+			return rule, Errorf("неверный формат RRULE: %s", part)
+		}
+		if seen[key] {
+			return rule, Errorf("параметр RRULE %s указан дважды", key)
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case "FREQ":
+			switch value {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				rule.freq = value
+			default:
+				return rule, Errorf("неподдерживаемое значение FREQ: %s", value)
+			}
+		case "INTERVAL":
+			rule.interval, err = parseRRuleInt(key, value, 1, 1000)
+		case "COUNT":
+			rule.count, err = parseRRuleInt(key, value, 1, 10000)
+		case "UNTIL":
+			rule.until, err = time.Parse("20060102", value[:min(len(value), 8)])
+			if err != nil || len(value) > 8 && value[8] != 'T' {
+				err = Errorf("неверный формат UNTIL: %s", value)
+			}
+		case "WKST":
+			day, ok := rruleWeekdays[value]
+			if !ok {
+				return rule, Errorf("неверный формат WKST: %s", value)
+			}
+			rule.wkst = day
+		case "BYDAY":
+			rule.byDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(value, ",") {
+				day, err := parseRRuleInt(key, item, -31, 31)
+				if err != nil || day == 0 {
+					return rule, Errorf("неверный формат BYMONTHDAY: %s", value)
+				}
+				rule.byMonthDay = append(rule.byMonthDay, day)
+			}
+		case "BYMONTH":
+			rule.byMonth = make(map[time.Month]bool)
+			for _, item := range strings.Split(value, ",") {
+				month, err := parseRRuleInt(key, item, 1, 12)
+				if err != nil {
+					return rule, err
+				}
+				rule.byMonth[time.Month(month)] = true
+			}
+		default:
+			return rule, Errorf("неподдерживаемый параметр RRULE: %s", key)
+		}
+		if err != nil {
+			return rule, err
+		}
+	}
+	if rule.freq == "" {
+		return rule, Errorf("в правиле RRULE не указан FREQ")
+	}
+	if rule.count > 0 && !rule.until.IsZero() {
+		return rule, Errorf("в правиле RRULE нельзя указывать одновременно COUNT и UNTIL")
+	}
+	for _, day := range rule.byDay {
+		if day.n == 0 {
+			continue
+		}
+		if rule.freq != "MONTHLY" && rule.freq != "YEARLY" {
+			return rule, Errorf("порядковый номер в BYDAY допустим только для FREQ=MONTHLY и FREQ=YEARLY")
+		}
+		if (rule.freq == "MONTHLY" || len(rule.byMonth) > 0) && (day.n > 5 || day.n < -5) {
+			return rule, Errorf("порядковый номер дня недели в месяце должен быть от -5 до 5")
+		}
+	}
+	return rule, nil
+}
+
+// parseRRuleInt разбирает целое значение параметра RRULE и проверяет, что оно лежит в диапазоне [low, high].
+func parseRRuleInt(key, value string, low, high int) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < low || number > high {
+		return 0, Errorf("неверный формат %s: %s", key, value)
+	}
+	return number, nil
+}
+
+// parseByDay разбирает значение BYDAY, например "MO,WE" или "2TU,-1FR".
+func parseByDay(value string) ([]weekdayNum, error) {
+	days := make([]weekdayNum, 0, 7)
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, Errorf("неверный формат BYDAY: %s", value)
+		}
+		day, ok := rruleWeekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, Errorf("неверный формат BYDAY: %s", value)
+		}
+		n := 0
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			var err error
+			n, err = strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n > 53 || n < -53 {
+				return nil, Errorf("неверный формат BYDAY: %s", value)
+			}
+		}
+		days = append(days, weekdayNum{n: n, day: day})
+	}
+	return days, nil
+}
+
+// next возвращает первое повторение правила, которое позже after, и его номер в серии (start - повторение с номером 0).
+// Если серия ограничена COUNT или UNTIL и закончилась раньше, возвращается ErrRepeatEnded.
+func (r rrule) next(start, after time.Time) (time.Time, int, error) {
+	index := 0
+	limit := after.AddDate(rruleSearchYears, 0, 0)
+	for day := start.AddDate(0, 0, 1); !day.After(limit); day = day.AddDate(0, 0, 1) {
+		if !r.until.IsZero() && day.After(r.until) {
+			return time.Time{}, 0, ErrRepeatEnded
+		}
+		if !r.matches(day, start) {
+			continue
+		}
+		index++
+		if r.count > 0 && index >= r.count {
+			return time.Time{}, 0, ErrRepeatEnded
+		}
+		if day.After(after) {
+			return day, index, nil
+		}
+	}
+	return time.Time{}, 0, Errorf("правило повторения не даёт дат в ближайшие %d лет", rruleSearchYears)
+}
+
+// matches проверяет, является ли день повторением правила с первым повторением start.
+// Параметры BY* ограничивают подходящие дни; если они не заданы, день месяца, месяц и день недели
+// берутся из start, как предписывает RFC 5545.
+func (r rrule) matches(day, start time.Time) bool {
+	var period int
+	switch r.freq {
+	case "DAILY":
+		period = daysBetween(start, day)
+	case "WEEKLY":
+		period = daysBetween(r.weekStart(start), r.weekStart(day)) / 7
+	case "MONTHLY":
+		period = (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+	case "YEARLY":
+		period = day.Year() - start.Year()
+	}
+	if period%r.interval != 0 {
+		return false
+	}
+	if len(r.byMonth) > 0 && !r.byMonth[day.Month()] {
+		return false
+	}
+	if len(r.byMonthDay) > 0 && !r.matchMonthDay(day) {
+		return false
+	}
+	if len(r.byDay) > 0 && !r.matchDay(day) {
+		return false
+	}
+	noDays := len(r.byDay) == 0 && len(r.byMonthDay) == 0
+	switch r.freq {
+	case "WEEKLY":
+		return len(r.byDay) > 0 || day.Weekday() == start.Weekday()
+	case "MONTHLY":
+		return !noDays || day.Day() == start.Day()
+	case "YEARLY":
+		if noDays {
+			return day.Day() == start.Day() && (len(r.byMonth) > 0 || day.Month() == start.Month())
+		}
+	}
+	return true
+}
+
+// matchMonthDay проверяет день по BYMONTHDAY; отрицательные значения отсчитываются от конца месяца.
+func (r rrule) matchMonthDay(day time.Time) bool {
+	last := daysIn(day.Year(), day.Month())
+	for _, monthDay := range r.byMonthDay {
+		if monthDay == day.Day() || monthDay < 0 && last+monthDay+1 == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDay проверяет день по BYDAY. Порядковый номер считается в пределах месяца,
+// а для FREQ=YEARLY без BYMONTH - в пределах года.
+func (r rrule) matchDay(day time.Time) bool {
+	inYear := r.freq == "YEARLY" && len(r.byMonth) == 0
+	for _, wd := range r.byDay {
+		if wd.day != day.Weekday() {
+			continue
+		}
+		if wd.n == 0 {
+			return true
+		}
+		position, total := day.Day(), daysIn(day.Year(), day.Month())
+		if inYear {
+			position, total = day.YearDay(), time.Date(day.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+		}
+		if wd.n > 0 && (position-1)/7+1 == wd.n || wd.n < 0 && (total-position)/7+1 == -wd.n {
+			return true
+		}
+	}
+	return false
+}
+
+// weekStart возвращает первый день недели, в которую входит day, с учётом WKST.
+func (r rrule) weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) - int(r.wkst) + 7) % 7))
+}
+
+// daysBetween возвращает число дней от from до to; обе даты - полночь UTC.
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// daysIn возвращает число дней в месяце.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// withCount заменяет значение COUNT в правиле RRULE, сохраняя остальные параметры.
+func withCount(repeat string, count int) string {
+	parts := strings.Split(repeat[len(rrulePrefix):], ";")
+	for i, part := range parts {
+		if key, _, _ := strings.Cut(part, "="); strings.EqualFold(strings.TrimSpace(key), "COUNT") {
+			parts[i] = "COUNT=" + strconv.Itoa(count)
+		}
+	}
+	return repeat[:len(rrulePrefix)] + strings.Join(parts, ";")
+}
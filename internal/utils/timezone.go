@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// locations - загруженные часовые пояса по имени IANA.
+var locations sync.Map
+
+// defaultLocation - часовой пояс задач без собственного пояса, читается из окружения один раз.
+var defaultLocation = sync.OnceValue(CheckTimezone)
+
+// CheckTimezone извлекает из переменной окружения "TODO_TIMEZONE" часовой пояс по умолчанию в формате IANA,
+// например "Europe/Moscow". Если переменная не установлена или пояс неизвестен, используется часовой пояс сервера.
+//
+// Возвращает:
+// Часовой пояс по умолчанию.
+func CheckTimezone() *time.Location {
+	name := os.Getenv("TODO_TIMEZONE")
+	if name == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("invalid TODO_TIMEZONE value %q, using server time zone", name)
+		return time.Local
+	}
+	return location
+}
+
+// Location возвращает часовой пояс по имени IANA; пустое имя означает пояс по умолчанию из TODO_TIMEZONE.
+// Загруженные пояса кэшируются.
+//
+// Параметры:
+// name: Имя часового пояса, например "Asia/Vladivostok".
+//
+// Возвращает:
+// Часовой пояс и ошибку, если пояс неизвестен.
+func Location(name string) (*time.Location, error) {
+	if name == "" {
+		return defaultLocation(), nil
+	}
+	if location, ok := locations.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, NewError(CodeInvalidTimezone, "timezone", "неизвестный часовой пояс %s", name)
+	}
+	locations.Store(name, location)
+	return location, nil
+}
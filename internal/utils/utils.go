@@ -1,10 +1,8 @@
 package utils
 
 import (
-	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -12,38 +10,45 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-// CheckDB проверяет, существует ли файл базы данных SQLite и возвращает его путь.
-// Если файла не существует, возвращается имя файла базы данных по умолчанию и флаг, указывающий, что базу данных необходимо установить.
+// CheckDB возвращает путь к файлу базы данных SQLite.
+// Путь берётся из переменной окружения TODO_DBFILE, а если она не установлена, используется "scheduler.db".
+// Если файла не существует, он будет создан при подключении, а схему создадут миграции.
 //
 // Возвращает:
 // path (string): Путь к файлу базы данных SQLite.
-// install (bool): Флаг, указывающий, что базу данных необходимо установить.
-func CheckDB() (string, bool) {
-
-	// Получение пути к файлу базы данных SQLite из переменной окружения TODO_DBFILE.
-	// Если переменная окружения не установлена, используется имя файла базы данных по умолчанию "scheduler.db".
+func CheckDB() string {
 	path := os.Getenv("TODO_DBFILE")
 	if path == "" {
 		path = "scheduler.db"
 	}
+	return path
+}
 
-	// Получение абсолютного пути к файлу базы данных SQLite путем объединения каталога исполняемого файла с именем файла базы данных.
-	appPath, err := os.Executable()
-	if err != nil {
-		log.Fatal(err)
+// CheckDSN возвращает строку подключения к хранилищу из переменной окружения "TODO_DSN".
+// Поддерживаются "memory://", "postgres://..." и "sqlite://<путь>".
+// Если переменная не установлена, используется файл SQLite, путь к которому возвращает CheckDB.
+//
+// Возвращает:
+// Строку подключения к хранилищу.
+func CheckDSN() string {
+	dsn := os.Getenv("TODO_DSN")
+	if dsn == "" {
+		dsn = "sqlite://" + CheckDB()
 	}
-	dbFile := filepath.Join(filepath.Dir(appPath), path)
-
-	// Проверка существования файла базы данных SQLite.
-	_, err = os.Stat(dbFile)
+	return dsn
+}
 
-	// Инициализация флага установки на false.
-	var install bool
-	if err != nil {
-		fmt.Println(err)
-		install = true
+// CheckPGDriver извлекает имя драйвера database/sql для PostgreSQL из переменной окружения "TODO_PG_DRIVER".
+// Если переменная не установлена, используется "pgx".
+//
+// Возвращает:
+// Имя драйвера.
+func CheckPGDriver() string {
+	driver := os.Getenv("TODO_PG_DRIVER")
+	if driver == "" {
+		driver = "pgx"
 	}
-	return path, install
+	return driver
 }
 
 // CheckPort извлекает номер порта из переменной окружения "TODO_PORT".
@@ -59,10 +64,91 @@ func CheckPort() string {
 	return port
 }
 
+// CheckPassword извлекает пароль приложения из переменной окружения "TODO_PASSWORD".
+// Пустая строка означает, что аутентификация отключена.
+//
+// Возвращает:
+// Пароль в виде строки.
+func CheckPassword() string {
+	return os.Getenv("TODO_PASSWORD")
+}
+
+// CheckTokenTTL извлекает время жизни токена доступа из переменной окружения "TODO_TOKEN_TTL".
+// Значение задаётся в формате time.ParseDuration (например, "8h" или "30m").
+// Если переменная не установлена или содержит неверное значение, используется 8 часов.
+//
+// Возвращает:
+// Время жизни токена доступа.
+func CheckTokenTTL() time.Duration {
+	return checkDuration("TODO_TOKEN_TTL", 8*time.Hour)
+}
+
+// CheckRefreshTTL извлекает время жизни токена обновления из переменной окружения "TODO_REFRESH_TTL".
+// Если переменная не установлена или содержит неверное значение, используется 30 дней.
+//
+// Возвращает:
+// Время жизни токена обновления.
+func CheckRefreshTTL() time.Duration {
+	return checkDuration("TODO_REFRESH_TTL", 30*24*time.Hour)
+}
+
+// CheckLoginPersist проверяет переменную окружения "TODO_LOGIN_PERSIST".
+// Если она равна "1" или "true", счётчики неудачных попыток входа сохраняются в базе данных и переживают перезапуск.
+//
+// Возвращает:
+// Флаг сохранения счётчиков.
+func CheckLoginPersist() bool {
+	persist, err := strconv.ParseBool(os.Getenv("TODO_LOGIN_PERSIST"))
+	return err == nil && persist
+}
+
+// CheckHolidaysFile извлекает из переменной окружения "TODO_HOLIDAYS" путь к файлу календаря праздников
+// в формате ICS или CSV (см. LoadHolidays). Пустая строка означает, что рабочими считаются все дни, кроме выходных.
+//
+// Возвращает:
+// Путь к файлу календаря.
+func CheckHolidaysFile() string {
+	return os.Getenv("TODO_HOLIDAYS")
+}
+
+// CheckRetentionDays извлекает из переменной окружения "TODO_RETENTION_DAYS" число дней,
+// в течение которых хранятся выполненные задачи.
+// Если переменная не установлена, не является числом или не положительна, возвращается 0, и выполненные задачи хранятся бессрочно.
+//
+// Возвращает:
+// Число дней хранения выполненных задач.
+func CheckRetentionDays() int {
+	value := os.Getenv("TODO_RETENTION_DAYS")
+	if value == "" {
+		return 0
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		log.Printf("invalid TODO_RETENTION_DAYS value %q, keeping completed tasks", value)
+		return 0
+	}
+	return days
+}
+
+// checkDuration читает длительность из переменной окружения env.
+// Если переменная пуста, не разбирается или не положительна, возвращается значение по умолчанию def.
+func checkDuration(env string, def time.Duration) time.Duration {
+	value := os.Getenv(env)
+	if value == "" {
+		return def
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("invalid %s value %q, using %s", env, value, def)
+		return def
+	}
+	return duration
+}
+
 // NextDate вычисляет следующую дату на основе указанного правила повторения и текущей даты.
 //
 // Параметры:
-// now: Текущая дата и время.
+// now: Текущая дата и время; текущим днём считается день now в его часовом поясе.
 // date: Строка даты в формате "20060102".
 // repeat: Строка правила повторения.
 //
@@ -73,18 +159,48 @@ func CheckPort() string {
 // - "d": Ежедневное повторение. Следующая дата вычисляется путем добавления указанного количества дней к текущей дате.
 // - "w": Еженедельное повторение. Задача назначается в указанные дни недели, где 1 — понедельник, 7 — воскресенье.
 // - "m": Ежемесячное повторение. Следующая дата вычисляется путем определения указанного дня указанного (опционально) месяца,
-// а также может определять последний и предпоследний день месяца (-1 и -2 соответственно).
+// а также может определять последний и предпоследний день месяца (-1 и -2 соответственно) с учётом длины каждого месяца.
 // - "y": Ежегодное повторение.
+// - "bd": Повторение через указанное число рабочих дней: выходные и праздники из календаря LoadHolidays не считаются.
+// - "mw": Ежемесячное повторение по дням недели: "mw 1:1,3:4" - первый понедельник и третий четверг месяца,
+// "mw -1:5 12" - последняя пятница декабря (см. CheckMonthWeekday).
+// - "RRULE:...": Правило в формате RFC 5545 (см. CheckRRule). Дата date считается первым повторением (DTSTART),
+// а результат - первое повторение, которое позже и date, и now. Если повторений больше нет, возвращается ErrRepeatEnded.
 //
 // Если текущая дата меньше вычисленной следующей даты, функция возвращает ошибку.
+// Ошибки имеют тип *Error с кодом CodeInvalidDate для неверной даты и CodeInvalidRepeat для неверного правила.
 func NextDate(now time.Time, date string, repeat string) (string, error) {
+	next, err := nextDate(now, date, repeat)
+	return next, repeatError(date, err)
+}
+
+// repeatError приводит ошибку вычисления даты повторения к *Error: неверная дата date получает код CodeInvalidDate,
+// остальные ошибки - CodeInvalidRepeat. Ошибки, у которых код уже есть, возвращаются без изменений.
+func repeatError(date string, err error) error {
+	if err == nil || ErrorCode(err) != "" {
+		return err
+	}
+	if _, dateErr := time.Parse("20060102", date); dateErr != nil {
+		return NewError(CodeInvalidDate, "date", "неверный формат даты %s", date).WithDetail("format", "20060102")
+	}
+	return NewError(CodeInvalidRepeat, "repeat", "%w", err)
+}
+
+// nextDate вычисляет для NextDate следующую дату по правилу повторения.
+func nextDate(now time.Time, date string, repeat string) (string, error) {
+	if IsRRule(repeat) {
+		return nextRRuleDate(now, date, repeat)
+	}
+	if strings.HasPrefix(repeat, monthWeekdayRule+" ") {
+		return nextMonthWeekdayDate(now, date, repeat)
+	}
 	daysInt, monthsInt := make([]int, 0, 7), make([]int, 0, 7)
 	var err error
 
 	repeatSlc := strings.Split(repeat, " ")
 	rule := repeatSlc[0]
-	if len(repeatSlc) > 3 || rule == "y" && len(repeatSlc) > 1 || rule == "d" && len(repeatSlc) == 1 || rule == "d" && len(repeatSlc) > 2 || rule == "w" && len(repeatSlc) > 2 {
-		return "", fmt.Errorf("неверный формат repeat")
+	if len(repeatSlc) > 3 || rule == "y" && len(repeatSlc) > 1 || rule == "d" && len(repeatSlc) == 1 || rule == "d" && len(repeatSlc) > 2 || rule == "w" && len(repeatSlc) > 2 || rule == businessDayRule && len(repeatSlc) != 2 {
+		return "", Errorf("неверный формат repeat")
 	}
 
 	if len(repeatSlc) > 1 {
@@ -94,10 +210,10 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 			dayToAppend, err = strconv.Atoi(day)
 			daysInt = append(daysInt, dayToAppend)
 			if daysInt[idx] > 400 {
-				return "", fmt.Errorf("неверный формат days (d>400)")
+				return "", Errorf("неверный формат days (d>400)")
 			}
 			if err != nil {
-				return "", err
+				return "", Errorf("неверный формат days")
 			}
 		}
 	}
@@ -107,27 +223,27 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 			monthToAppend, err := strconv.Atoi(month)
 			monthsInt = append(monthsInt, monthToAppend)
 			if monthsInt[idx] > 12 || monthsInt[idx] < 1 {
-				return "", fmt.Errorf("неверный формат month")
+				return "", Errorf("неверный формат month")
 			}
 			if err != nil {
-				return "", err
+				return "", Errorf("неверный формат month")
 			}
 		}
 	}
 	now, err = time.Parse("20060102", now.Format("20060102"))
 	if err != nil {
-		return "", fmt.Errorf("%s\nневерный формат now", err)
+		return "", Errorf("неверный формат текущей даты")
 	}
 	dateStart, err := time.Parse("20060102", date)
 	if err != nil {
-		return "", fmt.Errorf("%s\nневерный формат date", err)
+		return "", Errorf("неверный формат даты %s", date)
 	}
 
 	var resDate time.Time
 	switch rule {
 	case "":
 		if dateStart.Before(now) {
-			resDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			resDate = now
 			return resDate.Format("20060102"), nil
 		} else {
 			resDate = dateStart
@@ -140,7 +256,7 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 				return resDate.Format("20060102"), nil
 			}
 			resDate = dateStart.AddDate(0, 0, 1)
-			for resDate.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)) {
+			for resDate.Before(now) {
 				resDate = resDate.AddDate(0, 0, daysInt[0])
 			}
 			return resDate.Format("20060102"), nil
@@ -154,10 +270,12 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 		} else {
 			resDate = dateStart
 		}
-		for resDate.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)) {
+		for resDate.Before(now) {
 			resDate = resDate.AddDate(0, 0, daysInt[0])
 		}
 		return resDate.Format("20060102"), nil
+	case businessDayRule:
+		return nextBusinessDayDate(now, dateStart, daysInt)
 	case "y":
 		resDate = dateStart.AddDate(1, 0, 0)
 		for resDate.Before(now) {
@@ -170,7 +288,7 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 		wantedWeekdays := make(map[int]bool, 7)
 		for _, day := range Weekdays {
 			if day < 1 || day > 7 {
-				return "", fmt.Errorf("неверный формат weekdays")
+				return "", Errorf("неверный формат weekdays")
 			}
 			if day == 7 {
 				wantedWeekdays[0] = true
@@ -187,230 +305,27 @@ func NextDate(now time.Time, date string, repeat string) (string, error) {
 			resDate = resDate.AddDate(0, 0, 1)
 			if wantedWeekdays[int(resDate.Weekday())] {
 				if resDate.Before(now) {
-					return "", fmt.Errorf("полученная дата меньше текущей даты")
+					return "", Errorf("полученная дата меньше текущей даты")
 				}
 				return resDate.Format("20060102"), nil
 			}
 		}
 	case "m":
-		wantedMonthDays := make(map[int]bool, 31)
-		wantedMonths := make(map[int]bool, 12)
-		for _, month := range monthsInt {
-			if month < 1 || month > 12 {
-				return "", fmt.Errorf("неверный формат months")
-			}
-			wantedMonths[month] = true
+		rule, err := ruleOf(repeat)
+		if err != nil {
+			return "", err
 		}
-		for _, day := range daysInt {
-			if day > 31 || day < -2 {
-				return "", fmt.Errorf("неверный формат monthDays")
-			}
-			if day > 0 {
-				wantedMonthDays[day] = true
-			} else {
-				//подсчёт дня месяца для отрицательных значений
-				tempDay := countMonthDay(wantedMonths, now, dateStart, day, dateStart.Before(now))
-				wantedMonthDays[tempDay] = true
-			}
+		after := now
+		if dateStart.After(now) {
+			after = dateStart
 		}
-
-		resDate = dateStart
-		if len(wantedMonths) == 0 && (dateStart.Before(now) || dateStart == now) {
-			for day := range wantedMonthDays {
-				if time.Date(now.Year(), now.Month(), day, 0, 0, 0, 0, time.Local).Before(now) {
-					wantedMonths[int(now.Month())+1] = true
-				}
-			}
-			if len(wantedMonths) == 0 {
-				wantedMonths[int(now.Month())] = true
-			}
-		}
-
-		if len(wantedMonths) == 0 && now.Before(dateStart) {
-			for day := range wantedMonthDays {
-				var skip bool
-				tempDate := time.Date(dateStart.Year(), dateStart.Month(), day, 0, 0, 0, 0, time.Local)
-				if tempDate.Month() > dateStart.Month() {
-					tempDate = tempDate.AddDate(0, 0, -1)
-					if tempDate.Month() == 3 && dateStart.Month() == 2 {
-						tempDate = tempDate.AddDate(0, 0, -1)
-					}
-					skip = true
-				}
-				if tempDate.Before(dateStart) {
-					wantedMonths[int(dateStart.Month())] = true
-					if daysInt[0] == -1 || daysInt[0] == -2 {
-						for i := 1; i <= 12; i++ {
-							wantedMonths[i] = true
-						}
-					}
-				} else if tempDate.Day() > dateStart.Day() {
-					if skip {
-						wantedMonths[int(dateStart.Month())+1] = true
-					} else {
-						wantedMonths[int(dateStart.Month())] = true
-					}
-				}
-			}
-			if len(wantedMonths) == 0 {
-				wantedMonths[int(dateStart.Month())] = true
-				if daysInt[0] == -1 || daysInt[0] == -2 {
-					for i := 1; i <= 12; i++ {
-						wantedMonths[i] = true
-					}
-				}
-			}
-		}
-
-		for {
-			resDate = resDate.AddDate(0, 0, 1)
-			if wantedMonths[int(resDate.Month())] && wantedMonthDays[int(resDate.Day())] && now.Before(resDate) {
-				if resDate.Before(now) {
-					return "", fmt.Errorf("полученная дата меньше текущей даты")
-				}
-				return resDate.Format("20060102"), nil
-			}
+		next, _, err := rule.next(dateStart, after)
+		if err != nil {
+			return "", err
 		}
+		return next.Format("20060102"), nil
 	default:
-		return "", fmt.Errorf("неверный формат repeat")
-
-	}
-}
-
-// countMonthDay является вспомогательной функцией для функции NextDate, вычисляющей день месяца на основе заданных условий.
-// Она принимает в качестве параметров мапу месяцев, для которых требуется вычисление дня, текущую дату и время,
-// начальную дату, количество дней для вычитания и флаг, указывающий, является ли начальная дата раньше текущей даты.
-//
-// Параметры:
-// wantedMonths: Карта, содержащая целые числа, представляющая месяцы, для которых требуется вычисление дня.
-// now: Текущая дата и время.
-// dateStart: Начальная дата для вычисления дня.
-// subDays: Количество дней для вычитания из вычисленной даты.
-// isDateStartBeforeNow: Логический флаг, указывающий, является ли начальная дата раньше текущей даты.
-//
-// Возвращает:
-// Целое число, представляющее вычисленный день месяца.
-func countMonthDay(wantedMonths map[int]bool, now time.Time, dateStart time.Time, subDays int, isDateStartBeforeNow bool) int {
-	newMap := make(map[int]bool, 12)
-	for k, v := range wantedMonths {
-		newMap[k] = v
-	}
-	if isDateStartBeforeNow {
-		dateStart = now
-	}
-	var isFeb bool
-	var daysInMonth int
-	var desiredMonth int
-	currentMonth := int(dateStart.Month())
-	isFeb = currentMonth == 2
-	if isFeb && dateStart.Year()%4 == 0 {
-		if dateStart.Year()%100 == 0 {
-			if dateStart.Year()%400 == 0 {
-				daysInMonth = 29
-			} else {
-				daysInMonth = 28
-			}
-		} else {
-			daysInMonth = 29
-		}
-	} else if isFeb {
-		daysInMonth = 28
-	}
-	if len(newMap) == 0 {
-		if isFeb {
-			if dateStart.Day() <= 27 && daysInMonth == 29 {
-				if subDays == -1 {
-					return daysInMonth
-				} else {
-					return daysInMonth - 1
-				}
-			} else if dateStart.Day() <= 27 && daysInMonth == 28 {
-				if subDays == -1 {
-					return daysInMonth
-				} else {
-					return 30
-				}
-			}
-			if daysInMonth == 29 && dateStart.Day() == 29 {
-				if subDays == -1 {
-					return 31
-				} else {
-					return 30
-				}
-			} else if daysInMonth == 29 && dateStart.Day() == 28 {
-				if subDays == -1 {
-					return 29
-				} else {
-					return 30
-				}
-			} else if daysInMonth == 28 && dateStart.Day() == 28 {
-				if subDays == -1 {
-					return 31
-				} else {
-					return 30
-				}
-			} else if daysInMonth == 28 && dateStart.Day() == 27 {
-				if subDays == -1 {
-					return 28
-				} else {
-					return 30
-				}
-			}
-		} else if dateStart.Day() < 29 {
-			desiredMonth = currentMonth
-			newMap[currentMonth] = true
-		} else if dateStart.Day() == 30 && dateStart.AddDate(0, 0, 1).Day() == 31 {
-			if subDays == -1 {
-				desiredMonth = currentMonth
-				newMap[currentMonth] = true
-			} else {
-				desiredMonth = currentMonth + 1
-				if currentMonth == 12 {
-					desiredMonth = 1
-				}
-				newMap[desiredMonth] = true
-			}
-		} else if dateStart.Day() == 30 && dateStart.AddDate(0, 0, 1).Day() == 1 {
-			desiredMonth = currentMonth + 1
-			if currentMonth == 12 {
-				desiredMonth = 1
-			}
-			newMap[desiredMonth] = true
+		return "", Errorf("неверный формат repeat")
 
-		} else if dateStart.Day() == 31 {
-			desiredMonth = currentMonth + 1
-			if currentMonth == 12 {
-				desiredMonth = 1
-			}
-			newMap[desiredMonth] = true
-		} else if dateStart.Day() == 29 && dateStart.AddDate(0, 0, 2).Day() == 31 {
-			desiredMonth = currentMonth
-			newMap[currentMonth] = true
-		} else if dateStart.Day() == 29 && dateStart.AddDate(0, 0, 2).Day() == 1 {
-			if subDays == -1 {
-				desiredMonth = currentMonth
-				newMap[currentMonth] = true
-			} else {
-				desiredMonth = currentMonth + 1
-				if currentMonth == 12 {
-					desiredMonth = 1
-				}
-				newMap[desiredMonth] = true
-			}
-		}
-	}
-	if dateStart.Month() == time.Month(desiredMonth) {
-		for newMap[int(dateStart.Month())] {
-			dateStart = dateStart.AddDate(0, 0, 1)
-		}
-		dateStart = dateStart.AddDate(0, 0, subDays)
-		return dateStart.Day()
-	}
-	for {
-		dateStart = dateStart.AddDate(0, 0, 1)
-		if newMap[int(dateStart.Month())] {
-			dateStart = dateStart.AddDate(0, 1, subDays)
-			return dateStart.Day()
-		}
 	}
 }
@@ -1,10 +1,13 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"net/http"
+	"os"
+	"time"
+	_ "time/tzdata"
 
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"go.uber.org/zap"
 	_ "modernc.org/sqlite"
 
@@ -23,22 +26,55 @@ func main() {
 	sugar := logger.Sugar()
 	webDir := "./web" // Каталог, содержащий статические файлы для обслуживания
 
-	path, install := utils.CheckDB() // Функция для проверки и возврата пути к базе данных и флага установки
+	dsn := utils.CheckDSN() // Функция для проверки и возврата строки подключения к хранилищу
 
-	// Открываем подключение к базе данных
-	db, err := sql.Open("sqlite", path)
+	// Часы, по которым вычисляются даты задач; TODO_FAKE_NOW фиксирует текущее время для тестов
+	clock, err := utils.CheckClock()
 	if err != nil {
 		sugar.Fatal(err)
 	}
-	defer db.Close()
-	dbConnection := models.NewConnection(db, sugar)
-	if install {
-		err = dbConnection.InitDB()
+
+	// Открываем хранилище, выбранное по строке подключения
+	store, closeStore, err := models.OpenStore(dsn, utils.CheckPGDriver(), clock, sugar)
+	if err != nil {
+		sugar.Fatal(err)
+	}
+	defer closeStore()
+	dbConnection, isSQL := store.(*models.DBConnection)
+
+	// Команда "migrate" управляет схемой базы данных и не запускает сервер
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if !isSQL {
+			sugar.Fatal("migrations are supported only by SQL stores")
+		}
+		err = runMigrate(dbConnection, os.Args[2:])
+		if err != nil {
+			sugar.Fatal(err)
+		}
+		return
+	}
+
+	// Применяем недостающие миграции, чтобы схема существующей базы данных соответствовала текущей версии
+	if isSQL {
+		err = dbConnection.Migrate()
 		if err != nil {
-			sugar.Error(err)
+			sugar.Fatal(err)
 		}
 	}
-	handler := handlers.NewHandler(dbConnection, sugar)
+	// Загружаем календарь праздников для правила "bd" и переноса дат на рабочие дни
+	if path := utils.CheckHolidaysFile(); path != "" {
+		count, err := utils.LoadHolidays(path)
+		if err != nil {
+			sugar.Fatal(err)
+		}
+		sugar.Infof("Loaded %d holidays from %s", count, path)
+	}
+	handler := handlers.NewHandler(store, clock, sugar)
+
+	// Выполненные задачи старше срока хранения удаляются при запуске и затем раз в час
+	if days := utils.CheckRetentionDays(); days > 0 {
+		go purgeCompleted(store, days, clock, sugar)
+	}
 
 	// Создаем новый экземпляр http.Server с указанным портом
 	server := &http.Server{
@@ -48,13 +84,28 @@ func main() {
 	// Настраиваем маршрутизацию для обслуживания всех файлов в каталоге web и конечных точек API
 	http.Handle("/", http.FileServer(http.Dir(webDir)))
 	http.HandleFunc("/api/signin", handler.Authentication)
+	http.HandleFunc("POST /api/signup", handler.SignUp)
+	http.HandleFunc("POST /api/refresh", handler.Refresh)
+	http.HandleFunc("POST /api/signout", handler.SignOut)
 	http.HandleFunc("/api/nextdate", handler.NextDate)
-	http.HandleFunc("GET /api/task", handler.GetTask)
-	http.HandleFunc("PUT /api/task", handler.EditTask)
-	http.HandleFunc("POST /api/task", handler.AddTask)
-	http.HandleFunc("DELETE /api/task", handler.DeleteTask)
-	http.HandleFunc("/api/tasks", handler.GetAllTasks)
-	http.HandleFunc("/api/task/done", handler.TaskDone)
+	http.HandleFunc("GET /api/occurrences", handler.Occurrences)
+	// Маршруты для работы с задачами доступны только после аутентификации
+	http.HandleFunc("GET /api/task", handler.Auth(handler.GetTask))
+	http.HandleFunc("PUT /api/task", handler.Auth(handler.EditTask))
+	http.HandleFunc("POST /api/task", handler.Auth(handler.AddTask))
+	http.HandleFunc("DELETE /api/task", handler.Auth(handler.DeleteTask))
+	http.HandleFunc("/api/tasks", handler.Auth(handler.GetAllTasks))
+	http.HandleFunc("GET /api/agenda", handler.Auth(handler.Agenda))
+	http.HandleFunc("GET /api/calendar", handler.Auth(handler.Calendar))
+	http.HandleFunc("/api/task/done", handler.Auth(handler.TaskDone))
+	http.HandleFunc("/api/task/undone", handler.Auth(handler.TaskUndone))
+	http.HandleFunc("GET /api/task/history", handler.Auth(handler.TaskHistory))
+	http.HandleFunc("POST /api/task/skip", handler.Auth(handler.SkipTask))
+	http.HandleFunc("GET /api/task/skip", handler.Auth(handler.GetSkips))
+	http.HandleFunc("DELETE /api/task/skip", handler.Auth(handler.DeleteSkip))
+	http.HandleFunc("GET /api/keys", handler.Auth(handler.GetAPIKeys))
+	http.HandleFunc("POST /api/keys", handler.Auth(handler.AddAPIKey))
+	http.HandleFunc("DELETE /api/keys", handler.Auth(handler.DeleteAPIKey))
 
 	// Запускаем сервер и прослушиваем входящие подключения
 	sugar.Infof("Server started at %s", url)
@@ -63,3 +114,64 @@ func main() {
 		sugar.Panic(err)
 	}
 }
+
+// purgeCompleted периодически удаляет задачи, выполненные более days дней назад.
+// Первая очистка выполняется сразу, следующие - раз в час. Функция не возвращает управление.
+//
+// Параметры:
+// - store: Хранилище задач.
+// - days: Срок хранения выполненных задач в днях.
+// - clock: Часы, от текущего времени которых отсчитывается срок хранения.
+// - logger: Журнал.
+func purgeCompleted(store models.TaskStore, days int, clock utils.Clock, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		purged, err := store.PurgeCompleted(clock.Now().AddDate(0, 0, -days))
+		if err != nil {
+			logger.Errorw("error purging completed tasks", "error", err)
+		} else if purged > 0 {
+			logger.Infof("%d completed tasks older than %d days purged", purged, days)
+		}
+		<-ticker.C
+	}
+}
+
+// runMigrate выполняет подкоманду миграций схемы базы данных:
+// - "status" выводит список миграций и отметку о применении;
+// - "up" применяет все недостающие миграции;
+// - "down" откатывает последнюю применённую миграцию.
+//
+// Параметры:
+// - dbConnection: Подключение к базе данных.
+// - args: Аргументы командной строки после "migrate".
+//
+// Возвращает:
+// - Ошибку, если подкоманда неизвестна или завершилась неудачно.
+func runMigrate(dbConnection *models.DBConnection, args []string) error {
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "up":
+		return dbConnection.Migrate()
+	case "down":
+		return dbConnection.MigrateDown()
+	case "status":
+		statuses, err := dbConnection.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-32s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected status, up or down", command)
+	}
+}
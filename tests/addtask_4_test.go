@@ -9,7 +9,6 @@ import (
 	"net/http/cookiejar"
 	"strconv"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -106,7 +105,7 @@ func TestAddTask(t *testing.T) {
 			"Ожидается ошибка для задачи %v", v)
 	}
 
-	now := time.Now()
+	now := serverNow(t)
 
 	check := func() {
 		for _, v := range tbl {
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// agendaResponse - ответ /api/agenda.
+type agendaResponse struct {
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Overdue  []map[string]string `json:"overdue"`
+	Today    []map[string]string `json:"today"`
+	Tomorrow []map[string]string `json:"tomorrow"`
+	ThisWeek []map[string]string `json:"this_week"`
+	Later    []map[string]string `json:"later"`
+	NoDate   []map[string]string `json:"no_date"`
+}
+
+// getAgenda запрашивает повестку на days дней и возвращает по каждому сроку пары "id date" задач из ids.
+func getAgenda(t *testing.T, days string, ids ...string) (agendaResponse, map[string][]string) {
+	body, err := requestJSON("api/agenda?days="+days, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var agenda agendaResponse
+	assert.NoError(t, json.Unmarshal(body, &agenda), string(body))
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	buckets := make(map[string][]string)
+	for name, tasks := range map[string][]map[string]string{"overdue": agenda.Overdue, "today": agenda.Today,
+		"tomorrow": agenda.Tomorrow, "this_week": agenda.ThisWeek, "later": agenda.Later, "no_date": agenda.NoDate} {
+		for _, task := range tasks {
+			if wanted[task["id"]] {
+				buckets[name] = append(buckets[name], task["id"]+" "+task["date"])
+			}
+		}
+	}
+	return agenda, buckets
+}
+
+func TestAgenda(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	day := func(n int) string {
+		return now.AddDate(0, 0, n).Format(`20060102`)
+	}
+	// bucket возвращает срок повестки для даты через n дней
+	bucket := func(n int) string {
+		switch {
+		case n == 0:
+			return "today"
+		case n == 1:
+			return "tomorrow"
+		case n <= (7-int(now.Weekday()))%7:
+			return "this_week"
+		default:
+			return "later"
+		}
+	}
+
+	once := addTaskFields(t, map[string]any{"title": "Агенда: завтра", "date": day(1)})
+	counted := addTaskFields(t, map[string]any{"title": "Агенда: три дня", "date": day(0), "repeat": "d 1",
+		"repeat_count": "3"})
+	overdue := addTaskFields(t, map[string]any{"title": "Агенда: просрочена", "date": day(1)})
+	missed := addTaskFields(t, map[string]any{"title": "Агенда: каждые 3 дня", "date": day(1), "repeat": "d 3"})
+	far := addTaskFields(t, map[string]any{"title": "Агенда: через месяц", "date": day(30)})
+	ids := []string{once, counted, overdue, missed, far}
+	defer func() {
+		for _, id := range ids {
+			_, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+			assert.NoError(t, err)
+		}
+	}()
+	for id, date := range map[string]string{overdue: day(-3), missed: day(-2)} {
+		_, err := db.Exec(`UPDATE scheduler SET date = ? WHERE id = ?`, date, id)
+		assert.NoError(t, err)
+	}
+	_, err := postJSON("api/task/skip?id="+missed+"&date="+day(4), nil, http.MethodPost)
+	assert.NoError(t, err)
+
+	want := map[string][]string{"overdue": {overdue + " " + day(-3), missed + " " + day(-2)}}
+	for _, v := range []struct {
+		id string
+		n  int
+	}{{counted, 0}, {once, 1}, {counted, 1}, {missed, 1}, {counted, 2}} {
+		want[bucket(v.n)] = append(want[bucket(v.n)], v.id+" "+day(v.n))
+	}
+	agenda, got := getAgenda(t, "7", ids...)
+	assert.Equal(t, day(0), agenda.From)
+	assert.Equal(t, day(6), agenda.To)
+	assert.Equal(t, want, got)
+
+	_, got = getAgenda(t, "31", ids...)
+	assert.Contains(t, got["later"], far+" "+day(30))
+	assert.Contains(t, got["later"], missed+" "+day(7))
+	assert.NotContains(t, got["later"], missed+" "+day(4))
+
+	for _, days := range []string{"0", "93", "week"} {
+		status, ret := requestError(t, "api/agenda?days="+days, nil, http.MethodGet)
+		assert.Equal(t, http.StatusBadRequest, status, days)
+		assert.Equal(t, "invalid_request", ret.Code, days)
+		assert.Equal(t, "days", ret.Field, days)
+	}
+}
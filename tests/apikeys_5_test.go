@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiKey - ответ POST /api/keys.
+type apiKey struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Prefix     string `json:"prefix"`
+	Scope      string `json:"scope"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	Key        string `json:"key"`
+}
+
+// createAPIKey создаёт от имени владельца токена token API-ключ с областью действия scope.
+func createAPIKey(t *testing.T, token, name, scope string) apiKey {
+	status, body := authRequest(t, http.MethodPost, "api/keys", map[string]any{"name": name, "scope": scope}, bearer(token))
+	require.Equal(t, http.StatusOK, status, string(body))
+	var key apiKey
+	require.NoError(t, json.Unmarshal(body, &key))
+	require.NotEmpty(t, key.Key)
+	return key
+}
+
+// listAPIKeys возвращает API-ключи владельца токена token.
+func listAPIKeys(t *testing.T, token string) map[int64]apiKey {
+	status, body := authRequest(t, http.MethodGet, "api/keys", nil, bearer(token))
+	require.Equal(t, http.StatusOK, status, string(body))
+	var list struct {
+		Keys []apiKey `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(body, &list))
+	keys := make(map[int64]apiKey)
+	for _, key := range list.Keys {
+		assert.Empty(t, key.Key)
+		keys[key.ID] = key
+	}
+	return keys
+}
+
+// withAPIKey возвращает заголовок X-API-Key с ключом key.
+func withAPIKey(key string) map[string]string {
+	return map[string]string{"X-API-Key": key}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	authPassword(t)
+	_, owner := signup(t, uniqueLogin("keys"), "keys-password")
+	read := createAPIKey(t, owner.Token, "чтение", "read")
+	write := createAPIKey(t, owner.Token, "запись", "read-write")
+	assert.Equal(t, "read", read.Scope)
+	assert.Equal(t, "read-write", write.Scope)
+	assert.Empty(t, read.LastUsedAt)
+
+	status, body := authRequest(t, http.MethodPost, "api/task", map[string]any{"title": "Задача для ключей"}, bearer(owner.Token))
+	require.Equal(t, http.StatusOK, status, string(body))
+	var created struct {
+		ID json.Number `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(body, &created))
+	id := created.ID.String()
+
+	status, body = authRequest(t, http.MethodGet, "api/task?id="+id, nil, withAPIKey(read.Key))
+	assert.Equal(t, http.StatusOK, status, string(body))
+	status, _ = authRequest(t, http.MethodGet, "api/tasks", nil, withAPIKey(read.Key))
+	assert.Equal(t, http.StatusOK, status)
+
+	// ключ только для чтения не изменяет задачи
+	for _, v := range []struct {
+		method string
+		path   string
+		values map[string]any
+	}{
+		{http.MethodPost, "api/task", map[string]any{"title": "Запись ключом чтения"}},
+		{http.MethodPut, "api/task", map[string]any{"id": id, "title": "Запись ключом чтения"}},
+		{http.MethodPost, "api/task/done?id=" + id, nil},
+		{http.MethodDelete, "api/task?id=" + id, nil},
+	} {
+		status, body := authRequest(t, v.method, v.path, v.values, withAPIKey(read.Key))
+		assert.Equal(t, http.StatusForbidden, status, "%s %s: %s", v.method, v.path, body)
+		var ret apiError
+		assert.NoError(t, json.Unmarshal(body, &ret))
+		assert.Equal(t, "forbidden", ret.Code)
+	}
+	status, body = authRequest(t, http.MethodGet, "api/task?id="+id, nil, bearer(owner.Token))
+	require.Equal(t, http.StatusOK, status)
+	var task map[string]string
+	require.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "Задача для ключей", task["title"])
+
+	status, body = authRequest(t, http.MethodPut, "api/task", map[string]any{"id": id, "title": "Запись ключом", "date": task["date"]},
+		withAPIKey(write.Key))
+	assert.Equal(t, http.StatusOK, status, string(body))
+	status, body = authRequest(t, http.MethodDelete, "api/task?id="+id, nil, withAPIKey(write.Key))
+	assert.Equal(t, http.StatusOK, status, string(body))
+
+	status, _ = authRequest(t, http.MethodGet, "api/tasks", nil, withAPIKey(read.Key+"0"))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// API-ключом нельзя управлять API-ключами, даже ключом с правом записи
+	for _, v := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "api/keys"},
+		{http.MethodPost, "api/keys"},
+		{http.MethodDelete, fmt.Sprintf("api/keys?id=%d", read.ID)},
+	} {
+		status, _ := authRequest(t, v.method, v.path, map[string]any{"scope": "read"}, withAPIKey(write.Key))
+		assert.Equal(t, http.StatusForbidden, status, "%s %s", v.method, v.path)
+	}
+
+	keys := listAPIKeys(t, owner.Token)
+	require.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[read.ID].LastUsedAt)
+	assert.Equal(t, read.Prefix, keys[read.ID].Prefix)
+
+	status, body = authRequest(t, http.MethodDelete, fmt.Sprintf("api/keys?id=%d", read.ID), nil, bearer(owner.Token))
+	assert.Equal(t, http.StatusOK, status, string(body))
+	status, _ = authRequest(t, http.MethodGet, "api/tasks", nil, withAPIKey(read.Key))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = authRequest(t, http.MethodGet, "api/tasks", nil, withAPIKey(write.Key))
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestAPIKeyPrune(t *testing.T) {
+	authPassword(t)
+	_, owner := signup(t, uniqueLogin("prune"), "prune-password")
+	stale := createAPIKey(t, owner.Token, "старый", "read")
+	fresh := createAPIKey(t, owner.Token, "новый", "read")
+
+	db := openDB(t)
+	defer db.Close()
+	_, err := db.Exec(`UPDATE api_keys SET created_at = ?, last_used_at = NULL WHERE id = ?`,
+		serverNow(t).AddDate(0, 0, -40).Unix(), stale.ID)
+	require.NoError(t, err)
+
+	status, body := authRequest(t, http.MethodDelete, "api/keys?unused_days=30", nil, bearer(owner.Token))
+	require.Equal(t, http.StatusOK, status, string(body))
+	var ret struct {
+		Deleted int64 `json:"deleted"`
+	}
+	require.NoError(t, json.Unmarshal(body, &ret))
+	assert.Equal(t, int64(1), ret.Deleted)
+
+	keys := listAPIKeys(t, owner.Token)
+	assert.NotContains(t, keys, stale.ID)
+	assert.Contains(t, keys, fresh.ID)
+	status, _ = authRequest(t, http.MethodGet, "api/tasks", nil, withAPIKey(stale.Key))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = authRequest(t, http.MethodDelete, "api/keys?unused_days=-1", nil, bearer(owner.Token))
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain получает токен для остальных тестов, если сервер запущен с паролем TODO_PASSWORD,
+// а токен не указан в переменной Token.
+func TestMain(m *testing.M) {
+	if password := os.Getenv("TODO_PASSWORD"); password != "" && Token == "" {
+		body, err := requestJSON("api/signin", map[string]any{"password": password}, http.MethodPost)
+		var tokens tokenPair
+		if err == nil && json.Unmarshal(body, &tokens) == nil {
+			Token = tokens.Token
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// tokenPair - ответ /api/signin, /api/signup и /api/refresh.
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// authPassword возвращает пароль, с которым запущен сервер, из переменной окружения TODO_PASSWORD.
+// Если пароль не задан, аутентификация на сервере отключена и тест пропускается.
+func authPassword(t *testing.T) string {
+	password := os.Getenv("TODO_PASSWORD")
+	if password == "" {
+		t.Skip("TODO_PASSWORD не задан, аутентификация отключена")
+	}
+	return password
+}
+
+// authRequest выполняет запрос с заголовками headers, но без токена из переменной Token,
+// и возвращает код состояния и тело ответа.
+func authRequest(t *testing.T, method, apipath string, values map[string]any, headers map[string]string) (int, []byte) {
+	var data []byte
+	if len(values) > 0 {
+		var err error
+		data, err = json.Marshal(values)
+		require.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, body
+}
+
+// bearer возвращает заголовок Authorization с токеном token.
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// signin выполняет вход с логином login и паролем password и возвращает выданные токены.
+// Пустой логин означает вход по общему паролю.
+func signin(t *testing.T, login, password string) tokenPair {
+	values := map[string]any{"password": password}
+	if login != "" {
+		values["login"] = login
+	}
+	status, body := authRequest(t, http.MethodPost, "api/signin", values, nil)
+	require.Equal(t, http.StatusOK, status, string(body))
+	var tokens tokenPair
+	require.NoError(t, json.Unmarshal(body, &tokens))
+	require.NotEmpty(t, tokens.Token)
+	require.NotEmpty(t, tokens.RefreshToken)
+	return tokens
+}
+
+// signToken подписывает ключом key токен доступа пользователя по умолчанию, действующий до exp.
+func signToken(t *testing.T, key, password string, exp time.Time) string {
+	hash := sha256.Sum256([]byte(password))
+	jti := make([]byte, 16)
+	_, err := rand.Read(jti)
+	require.NoError(t, err)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"hashedPass": hex.EncodeToString(hash[:]),
+		"token_type": "access",
+		"sub":        "1",
+		"jti":        hex.EncodeToString(jti),
+		"iat":        exp.Add(-time.Hour).Unix(),
+		"exp":        exp.Unix(),
+	}).SignedString([]byte(key))
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthRequired(t *testing.T) {
+	password := authPassword(t)
+	tokens := signin(t, "", password)
+
+	tampered := []byte(tokens.Token)
+	if tampered[len(tampered)-2] == 'A' {
+		tampered[len(tampered)-2] = 'B'
+	} else {
+		tampered[len(tampered)-2] = 'A'
+	}
+	for name, headers := range map[string]map[string]string{
+		"без токена":         nil,
+		"пустой токен":       bearer(""),
+		"изменённая подпись": bearer(string(tampered)),
+		"истёкший токен":     bearer(signToken(t, password, password, serverNow(t).Add(-time.Minute))),
+		"другой пароль":      bearer(signToken(t, password+"x", password+"x", serverNow(t).Add(time.Hour))),
+		"токен обновления":   bearer(tokens.RefreshToken),
+		"неверная cookie":    {"Cookie": "token=" + string(tampered)},
+	} {
+		for _, route := range []struct {
+			method string
+			path   string
+		}{
+			{http.MethodGet, "api/tasks"},
+			{http.MethodGet, "api/task?id=1"},
+			{http.MethodPost, "api/task"},
+			{http.MethodPost, "api/task/done?id=1"},
+		} {
+			status, body := authRequest(t, route.method, route.path, map[string]any{"title": "Без токена"}, headers)
+			assert.Equal(t, http.StatusUnauthorized, status, "%s: %s %s", name, route.method, route.path)
+			var ret apiError
+			assert.NoError(t, json.Unmarshal(body, &ret), string(body))
+			assert.Equal(t, "unauthorized", ret.Code, "%s: %s %s", name, route.method, route.path)
+		}
+	}
+
+	status, body := authRequest(t, http.MethodGet, "api/tasks", nil, bearer(tokens.Token))
+	assert.Equal(t, http.StatusOK, status, string(body))
+	status, body = authRequest(t, http.MethodGet, "api/tasks", nil, map[string]string{"Cookie": "token=" + tokens.Token})
+	assert.Equal(t, http.StatusOK, status, string(body))
+	status, body = authRequest(t, http.MethodGet, "api/tasks", nil,
+		bearer(signToken(t, password, password, serverNow(t).Add(time.Hour))))
+	assert.Equal(t, http.StatusOK, status, string(body))
+
+	// вычисление следующей даты доступно без аутентификации
+	status, _ = authRequest(t, http.MethodGet, "api/nextdate?now=20240126&date=20240126&repeat=d%201", nil, nil)
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextDateBusinessDays(t *testing.T) {
+	tbl := []nextDate{
+		{"20240126", "bd 1", "20240129"},
+		{"20240126", "bd 3", "20240131"},
+		{"20240119", "bd 5", "20240202"},
+		{"20240127", "bd 1", "20240129"},
+		{"20240201", "bd 2", "20240205"},
+		{"20240126", "bd", ""},
+		{"20240126", "bd 0", ""},
+		{"20240126", "bd 1,2", ""},
+	}
+	for _, v := range tbl {
+		urlPath := fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s",
+			url.QueryEscape(v.date), url.QueryEscape(v.repeat))
+		get, err := getBody(urlPath)
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(get))
+		_, err = time.Parse("20060102", next)
+		if err != nil && len(v.want) == 0 {
+			continue
+		}
+		assert.Equal(t, v.want, next, `{%q, %q, %q}`, v.date, v.repeat, v.want)
+	}
+}
+
+func TestRoll(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	// ближайшая суббота не раньше чем через два дня, чтобы пятница перед ней была позже сегодняшнего дня
+	saturday := serverNow(t).AddDate(0, 0, 2)
+	for saturday.Weekday() != time.Saturday {
+		saturday = saturday.AddDate(0, 0, 1)
+	}
+	friday := saturday.AddDate(0, 0, -1)
+	monday := saturday.AddDate(0, 0, 2)
+
+	ret, err := postJSON("api/task", map[string]any{"title": "Неверная политика", "roll": "sideways"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	dates := map[string]string{"": saturday.Format(`20060102`), "forward": monday.Format(`20060102`),
+		"backward": friday.Format(`20060102`)}
+	for roll, want := range dates {
+		id := addTaskFields(t, map[string]any{
+			"title": "Отчёт",
+			"date":  saturday.Format(`20060102`),
+			"roll":  roll,
+		})
+		var task Task
+		err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, want, task.Date, roll)
+		assert.Equal(t, roll, task.Roll)
+	}
+
+	// "d 8" от пятницы попадает на субботу
+	for roll, want := range map[string]time.Time{"forward": monday.AddDate(0, 0, 7), "backward": friday.AddDate(0, 0, 7)} {
+		id := addTaskFields(t, map[string]any{
+			"title":  "Уборка",
+			"date":   friday.Format(`20060102`),
+			"repeat": "d 8",
+			"roll":   roll,
+		})
+		ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+		var task Task
+		err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, want.Format(`20060102`), task.Date, roll)
+	}
+
+	id := addTaskFields(t, map[string]any{
+		"title":  "Проверить почту",
+		"date":   friday.Format(`20060102`),
+		"repeat": "bd 1",
+	})
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, monday.Format(`20060102`), task.Date)
+}
+
+func TestRollKeepsSchedule(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	// суббота, через год после которой воскресенье: ежегодная задача дважды подряд попадает на выходной
+	saturday := serverNow(t).AddDate(0, 0, 1)
+	for saturday.Weekday() != time.Saturday || saturday.AddDate(1, 0, 0).Weekday() != time.Sunday {
+		saturday = saturday.AddDate(0, 0, 1)
+	}
+	dates := []struct {
+		date     time.Time
+		schedule time.Time
+	}{
+		{saturday.AddDate(0, 0, 2), saturday},
+		{saturday.AddDate(1, 0, 1), saturday.AddDate(1, 0, 0)},
+		// через два года дата снова рабочий день и не сдвигается вслед за переносами
+		{saturday.AddDate(2, 0, 0), time.Time{}},
+	}
+
+	id := addTaskFields(t, map[string]any{
+		"title":  "Годовой отчёт",
+		"date":   saturday.Format(`20060102`),
+		"repeat": "y",
+		"roll":   "forward",
+	})
+	defer func() {
+		_, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+	}()
+	for i, want := range dates {
+		if i > 0 {
+			ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+			assert.NoError(t, err)
+			assert.Empty(t, ret)
+		}
+		var task Task
+		err := db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+		assert.NoError(t, err)
+		assert.Equal(t, want.date.Format(`20060102`), task.Date, i)
+		if want.schedule.IsZero() {
+			assert.Empty(t, task.ScheduleDate, i)
+		} else {
+			assert.Equal(t, want.schedule.Format(`20060102`), task.ScheduleDate, i)
+		}
+	}
+
+	// дата расписания сохраняется, пока пользователь не изменит дату задачи
+	monday := saturday.AddDate(0, 0, 2).Format(`20060102`)
+	rolled := addTaskFields(t, map[string]any{"title": "Уборка", "date": saturday.Format(`20060102`), "repeat": "d 7",
+		"roll": "forward"})
+	defer func() {
+		_, err := postJSON("api/task?id="+rolled, nil, http.MethodDelete)
+		assert.NoError(t, err)
+	}()
+	for _, v := range []struct {
+		date     string
+		schedule string
+	}{
+		{monday, saturday.Format(`20060102`)},
+		{saturday.AddDate(0, 0, 3).Format(`20060102`), ""},
+	} {
+		_, err := postJSON("api/task", map[string]any{"id": rolled, "title": "Уборка в офисе", "date": v.date,
+			"repeat": "d 7", "roll": "forward"}, http.MethodPut)
+		assert.NoError(t, err)
+		task := getTaskFields(t, rolled)
+		assert.Equal(t, v.date, task["date"])
+		assert.Equal(t, v.schedule, task["schedule_date"])
+	}
+}
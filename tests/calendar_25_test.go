@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// calendarResponse - ответ /api/calendar.
+type calendarResponse struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Occurrences []struct {
+		ID      string `json:"id"`
+		Date    string `json:"date"`
+		Virtual bool   `json:"virtual"`
+	} `json:"occurrences"`
+	Truncated bool `json:"truncated"`
+}
+
+// getCalendar запрашивает повторения задач с датами от from до to.
+func getCalendar(t *testing.T, from, to string) calendarResponse {
+	body, err := requestJSON("api/calendar?"+url.Values{"from": {from}, "to": {to}}.Encode(), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var calendar calendarResponse
+	assert.NoError(t, json.Unmarshal(body, &calendar), string(body))
+	return calendar
+}
+
+func TestCalendar(t *testing.T) {
+	now := serverNow(t)
+	day := func(n int) string {
+		return now.AddDate(0, 0, n).Format(`20060102`)
+	}
+
+	repeating := addTaskFields(t, map[string]any{"title": "Календарь: через день", "date": day(0), "repeat": "d 2"})
+	once := addTaskFields(t, map[string]any{"title": "Календарь: один раз", "date": day(3)})
+	ids := []string{repeating, once}
+	defer func() {
+		for _, id := range ids {
+			_, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+			assert.NoError(t, err)
+		}
+	}()
+	_, err := postJSON("api/task/skip?id="+repeating+"&date="+day(4), nil, http.MethodPost)
+	assert.NoError(t, err)
+
+	calendar := getCalendar(t, now.Format(`02.01.2006`), day(8))
+	assert.Equal(t, day(0), calendar.From)
+	assert.Equal(t, day(8), calendar.To)
+	assert.False(t, calendar.Truncated)
+	var got []string
+	for _, occurrence := range calendar.Occurrences {
+		if occurrence.ID == repeating || occurrence.ID == once {
+			got = append(got, fmt.Sprintf("%s %s %t", occurrence.ID, occurrence.Date, occurrence.Virtual))
+		}
+	}
+	assert.Equal(t, []string{
+		repeating + " " + day(0) + " false",
+		repeating + " " + day(2) + " true",
+		once + " " + day(3) + " false",
+		repeating + " " + day(6) + " true",
+		repeating + " " + day(8) + " true",
+	}, got)
+
+	for i := 0; i < 3; i++ {
+		ids = append(ids, addTaskFields(t, map[string]any{"title": "Календарь: каждый день", "date": day(0), "repeat": "d 1"}))
+	}
+	calendar = getCalendar(t, day(0), day(365))
+	assert.True(t, calendar.Truncated)
+	assert.Len(t, calendar.Occurrences, 1000)
+
+	for _, v := range []struct {
+		query string
+		code  string
+		field string
+	}{
+		{"to=" + day(1), "invalid_request", "from"},
+		{"from=" + day(0), "invalid_request", "to"},
+		{"from=tomorrow&to=" + day(1), "invalid_date", "from"},
+		{"from=" + day(1) + "&to=" + day(0), "invalid_request", "to"},
+		{"from=" + day(0) + "&to=" + day(366), "invalid_request", "to"},
+	} {
+		status, ret := requestError(t, "api/calendar?"+v.query, nil, http.MethodGet)
+		assert.Equal(t, http.StatusBadRequest, status, v.query)
+		assert.Equal(t, v.code, ret.Code, v.query)
+		assert.Equal(t, v.field, ret.Field, v.query)
+	}
+}
+
+func TestCalendarAfterAnniversary(t *testing.T) {
+	tbl := []struct {
+		repeat string
+		from   string
+		to     string
+		want   []string
+	}{
+		{"y", "20311018", "20321017", []string{"20321017"}},
+		{"m 17", "20301118", "20301231", []string{"20301217"}},
+		{"mw 3:4", "20301122", "20301231", []string{"20301219"}},
+	}
+	for _, v := range tbl {
+		id := addTaskFields(t, map[string]any{"title": "Календарь: годовщина", "date": "20301017", "repeat": v.repeat})
+		var got []string
+		for _, occurrence := range getCalendar(t, v.from, v.to).Occurrences {
+			if occurrence.ID == id {
+				assert.True(t, occurrence.Virtual, v.repeat)
+				got = append(got, occurrence.Date)
+			}
+		}
+		assert.Equal(t, v.want, got, v.repeat)
+		_, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getTasksByStatus(t *testing.T, status string) []map[string]string {
+	body, err := requestJSON("api/tasks?status="+status, nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m map[string][]map[string]string
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+	return m["tasks"]
+}
+
+func hasTask(tasks []map[string]string, id string) bool {
+	for _, task := range tasks {
+		if task["id"] == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCompletedTasks(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	id := addTask(t, task{
+		title:   "Сдать отчёт",
+		comment: "до обеда",
+	})
+	assert.True(t, hasTask(getTasksByStatus(t, ""), id))
+
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	assert.False(t, hasTask(getTasksByStatus(t, ""), id))
+	assert.True(t, hasTask(getTasksByStatus(t, "done"), id))
+	assert.True(t, hasTask(getTasksByStatus(t, "all"), id))
+
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var completed map[string]string
+	err = json.Unmarshal(body, &completed)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, completed["completed_at"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	ret, err = postJSON("api/task/undone?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.True(t, hasTask(getTasksByStatus(t, ""), id))
+	assert.False(t, hasTask(getTasksByStatus(t, "done"), id))
+
+	ret, err = postJSON("api/task/undone?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	ret, err = postJSON("api/task/undone?id=99999999", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	body, err = requestJSON("api/tasks?status=unknown", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string]any
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+	assert.Contains(t, m, "error")
+}
@@ -3,7 +3,6 @@ package tests
 import (
 	"os"
 	"testing"
-	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
@@ -16,6 +15,19 @@ type Task struct {
 	Title   string `db:"title"`
 	Comment string `db:"comment"`
 	Repeat  string `db:"repeat"`
+	UserID  int64  `db:"user_id"`
+	Version int64  `db:"version"`
+	// CompletedAt - время выполнения одноразовой задачи в секундах Unix, 0 у активных задач
+	CompletedAt int64  `db:"completed_at"`
+	RepeatUntil string `db:"repeat_until"`
+	RepeatCount int64  `db:"repeat_count"`
+	Roll        string `db:"roll"`
+	DueTime     string `db:"due_time"`
+	Timezone    string `db:"timezone"`
+	Priority    int64  `db:"priority"`
+	Tags        string `db:"tags"`
+	// ScheduleDate - дата по правилу повторения до переноса по политике roll
+	ScheduleDate string `db:"schedule_date"`
 }
 
 func count(db *sqlx.DB) (int, error) {
@@ -41,7 +53,7 @@ func TestDB(t *testing.T) {
 	before, err := count(db)
 	assert.NoError(t, err)
 
-	today := time.Now().Format(`20060102`)
+	today := serverNow(t).Format(`20060102`)
 
 	res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) 
 	VALUES (?, 'Todo', 'Комментарий', '')`, today)
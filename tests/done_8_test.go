@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoneErrors(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	ret, err := postJSON("api/task/done?id=99999999", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	ret, err = postJSON("api/task/done?id=qwerty", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	id := addTask(t, task{
+		title:  "Проверить версию задачи",
+		repeat: "d 2",
+	})
+	var before Task
+	err = db.Get(&before, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+
+	ret, err = postJSON(fmt.Sprintf("api/task/done?id=%s&version=%d", id, before.Version+1), nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	var after Task
+	err = db.Get(&after, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, before.Date, after.Date)
+	assert.Equal(t, before.Version, after.Version)
+}
+
+func TestDoneConcurrent(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	id := addTask(t, task{
+		title:  "Нажать «выполнено» дважды",
+		repeat: "d 3",
+	})
+	var before Task
+	err := db.Get(&before, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+
+	const clicks = 5
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		passed int
+	)
+	for i := 0; i < clicks; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ret, err := postJSON(fmt.Sprintf("api/task/done?id=%s&version=%d", id, before.Version), nil, http.MethodPost)
+			assert.NoError(t, err)
+			if len(ret) == 0 {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, passed)
+
+	var after Task
+	err = db.Get(&after, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), after.Date)
+	assert.Equal(t, before.Version+1, after.Version)
+}
+
+// TestDoneConcurrentDate повторяет одновременные нажатия «выполнено» в веб-интерфейсе, который передаёт
+// не версию, а дату задачи: перенести задачу должен только один запрос.
+func TestDoneConcurrentDate(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	id := addTask(t, task{
+		title:  "Нажать «выполнено» дважды без версии",
+		repeat: "d 3",
+	})
+	var before Task
+	err := db.Get(&before, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+
+	const clicks = 5
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		passed   int
+		statuses []int
+	)
+	for i := 0; i < clicks; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, ret := requestError(t, fmt.Sprintf("api/task/done?id=%s&date=%s", id, before.Date), nil, http.MethodPost)
+			mu.Lock()
+			defer mu.Unlock()
+			if status == http.StatusOK && ret.Error == "" {
+				passed++
+				return
+			}
+			statuses = append(statuses, status)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, passed)
+	for _, status := range statuses {
+		assert.Equal(t, http.StatusConflict, status)
+	}
+
+	var after Task
+	err = db.Get(&after, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), after.Date)
+	assert.Equal(t, before.Version+1, after.Version)
+
+	var completions int
+	err = db.Get(&completions, `SELECT COUNT(*) FROM task_completions WHERE task_id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, completions)
+
+	status, ret := requestError(t, fmt.Sprintf("api/task/done?id=%s&date=ooops", id), nil, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalid_date", ret.Code)
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type apiError struct {
+	Error   string            `json:"error"`
+	Code    string            `json:"code"`
+	Field   string            `json:"field"`
+	Details map[string]string `json:"details"`
+}
+
+// requestError выполняет запрос и разбирает ответ с ошибкой вместе с HTTP-кодом состояния.
+func requestError(t *testing.T, apipath string, values map[string]any, method string) (int, apiError) {
+	var data []byte
+	if len(values) > 0 {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return 0, apiError{}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	var ret apiError
+	assert.NoError(t, json.Unmarshal(body, &ret), string(body))
+	return resp.StatusCode, ret
+}
+
+func TestErrorCodes(t *testing.T) {
+	tbl := []struct {
+		path   string
+		values map[string]any
+		method string
+		status int
+		code   string
+		field  string
+	}{
+		{"api/task", map[string]any{"title": ""}, http.MethodPost, http.StatusBadRequest, "invalid_title", "title"},
+		{"api/task", map[string]any{"title": "Задача", "date": "2024"}, http.MethodPost, http.StatusBadRequest, "invalid_date", "date"},
+		{"api/task", map[string]any{"title": "Задача", "repeat": "x 1"}, http.MethodPost, http.StatusBadRequest, "invalid_repeat", "repeat"},
+		{"api/task", map[string]any{"title": "Задача", "repeat": "RRULE:FREQ=HOURLY"}, http.MethodPost, http.StatusBadRequest, "invalid_repeat", "repeat"},
+		{"api/task", map[string]any{"title": "Задача", "time": "9:30"}, http.MethodPost, http.StatusBadRequest, "invalid_time", "time"},
+		{"api/task", map[string]any{"title": "Задача", "timezone": `Mars/"Olympus"`}, http.MethodPost, http.StatusBadRequest, "invalid_timezone", "timezone"},
+		{"api/task", map[string]any{"title": "Задача", "repeat_count": "-1"}, http.MethodPost, http.StatusBadRequest, "invalid_repeat_end", "repeat_count"},
+		{"api/task?id=qwerty", nil, http.MethodGet, http.StatusBadRequest, "invalid_id", "id"},
+		{"api/task?id=99999999", nil, http.MethodGet, http.StatusNotFound, "not_found", "id"},
+		{"api/task?id=99999999", nil, http.MethodDelete, http.StatusNotFound, "not_found", "id"},
+		{"api/task/done?id=99999999", nil, http.MethodPost, http.StatusNotFound, "not_found", "id"},
+		{"api/task", map[string]any{"id": "99999999", "title": "Задача", "date": "20240126"}, http.MethodPut, http.StatusNotFound, "not_found", "id"},
+		{"api/nextdate?now=20240126&date=20240126&repeat=k%2034", nil, http.MethodGet, http.StatusBadRequest, "invalid_repeat", "repeat"},
+		{"api/nextdate?now=20240126&date=ooops&repeat=y", nil, http.MethodGet, http.StatusBadRequest, "invalid_date", "date"},
+		{"api/tasks?status=unknown", nil, http.MethodGet, http.StatusBadRequest, "invalid_request", "status"},
+	}
+	for _, v := range tbl {
+		name := fmt.Sprintf("%s %s %v", v.method, v.path, v.values)
+		status, ret := requestError(t, v.path, v.values, v.method)
+		assert.Equal(t, v.status, status, name)
+		assert.Equal(t, v.code, ret.Code, name)
+		assert.Equal(t, v.field, ret.Field, name)
+		assert.NotEmpty(t, ret.Error, name)
+	}
+
+	_, ret := requestError(t, "api/task", map[string]any{"title": "Задача", "date": "2024"}, http.MethodPost)
+	assert.Equal(t, "20060102", ret.Details["format"])
+}
+
+func TestErrorConflict(t *testing.T) {
+	id := addTask(t, task{
+		title:  "Проверить код конфликта",
+		repeat: "d 2",
+	})
+	stored := getTaskFields(t, id)
+	status, ret := requestError(t, fmt.Sprintf("api/task/done?id=%s&version=%s1", id, stored["version"]), nil, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "conflict", ret.Code)
+
+	status, ret = requestError(t, "api/task/skip?id="+addTask(t, task{title: "Одноразовая задача"}), nil, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "not_repeating", ret.Code)
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeNowValue возвращает значение TODO_FAKE_NOW, на котором зафиксированы часы сервера, или FakeNow из settings.go.
+func fakeNowValue() string {
+	if env := os.Getenv("TODO_FAKE_NOW"); len(env) > 0 {
+		return env
+	}
+	return FakeNow
+}
+
+// serverNow возвращает текущий момент по часам сервера: TODO_FAKE_NOW, если часы зафиксированы, иначе системное время.
+// Значение разбирается так же, как на сервере: "20060102", "20060102 15:04" в поясе TODO_TIMEZONE или RFC 3339.
+// Тесты, которые вычисляют ожидаемые даты, берут сегодняшний день отсюда, а не из time.Now().
+func serverNow(t *testing.T) time.Time {
+	value := fakeNowValue()
+	if len(value) == 0 {
+		return time.Now()
+	}
+	if now, err := time.Parse(time.RFC3339, value); err == nil {
+		return now
+	}
+	location := time.Local
+	if name := os.Getenv("TODO_TIMEZONE"); len(name) > 0 {
+		var err error
+		location, err = time.LoadLocation(name)
+		if err != nil {
+			t.Fatalf("неизвестный часовой пояс TODO_TIMEZONE: %s", name)
+		}
+	}
+	for _, layout := range []string{`20060102`, `20060102 15:04`} {
+		if now, err := time.ParseInLocation(layout, value, location); err == nil {
+			return now
+		}
+	}
+	t.Fatalf("TODO_FAKE_NOW должен быть в формате 20060102, 20060102 15:04 или RFC 3339: %s", value)
+	return time.Time{}
+}
+
+// fakeNow возвращает момент, на котором зафиксированы часы сервера. Если часы не зафиксированы, тест пропускается.
+func fakeNow(t *testing.T) time.Time {
+	if len(fakeNowValue()) == 0 {
+		t.Skip("TODO_FAKE_NOW is not set")
+	}
+	return serverNow(t)
+}
+
+func TestFakeNow(t *testing.T) {
+	now := fakeNow(t)
+	today := now.Format(`20060102`)
+
+	id := addTaskFields(t, map[string]any{"title": "Задача на сегодня"})
+	assert.Equal(t, today, getTaskFields(t, id)["date"])
+
+	id = addTaskFields(t, map[string]any{"title": "Ежедневная задача", "repeat": "d 1"})
+	assert.Equal(t, today, getTaskFields(t, id)["date"])
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	task := getTaskFields(t, id)
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), task["date"])
+
+	// прошедшая дата без правила повторения заменяется сегодняшней
+	id = addTaskFields(t, map[string]any{"title": "Просроченная задача", "date": now.AddDate(0, 0, -1).Format(`20060102`)})
+	assert.Equal(t, today, getTaskFields(t, id)["date"])
+
+	// перенести задачу на вчера нельзя, на сегодня - можно
+	for date, valid := range map[string]bool{now.AddDate(0, 0, -1).Format(`20060102`): false, today: true} {
+		ret, err = postJSON("api/task", map[string]any{"id": id, "title": "Перенесённая задача", "date": date},
+			http.MethodPut)
+		assert.NoError(t, err)
+		if valid {
+			assert.Empty(t, ret, date)
+		} else {
+			assert.Contains(t, ret, "error", date)
+		}
+	}
+}
+
+func TestFakeNowLeapDay(t *testing.T) {
+	now := fakeNow(t)
+	if now.Month() != time.February || now.Day() != 29 {
+		t.Skip("TODO_FAKE_NOW is not Feb 29")
+	}
+	today := now.Format(`20060102`)
+
+	id := addTaskFields(t, map[string]any{"title": "Годовщина", "date": today, "repeat": "y"})
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Equal(t, time.Date(now.Year()+1, time.March, 1, 0, 0, 0, 0, time.UTC).Format(`20060102`),
+		getTaskFields(t, id)["date"])
+
+	id = addTaskFields(t, map[string]any{"title": "Конец месяца", "date": today, "repeat": "m -1"})
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Equal(t, time.Date(now.Year(), time.March, 31, 0, 0, 0, 0, time.UTC).Format(`20060102`),
+		getTaskFields(t, id)["date"])
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	day := func(n int) string {
+		return now.AddDate(0, 0, n).Format(`20060102`)
+	}
+	a := addTaskFields(t, map[string]any{"title": "Созвон с командой", "comment": "filter22", "date": day(10),
+		"repeat": "d 7", "tags": "work", "priority": "2"})
+	b := addTaskFields(t, map[string]any{"title": "Отчёт", "comment": "filter22", "date": day(1),
+		"tags": "work,urgent", "priority": "3"})
+	c := addTaskFields(t, map[string]any{"title": "Покупки", "comment": "filter22", "date": day(1), "tags": "home"})
+	d := addTaskFields(t, map[string]any{"title": "Старая задача", "comment": "filter22"})
+	_, err := db.Exec(`UPDATE scheduler SET date = ? WHERE id = ?`, day(-3), d)
+	assert.NoError(t, err)
+	defer func() {
+		for _, id := range []string{a, b, c, d} {
+			_, err := db.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+			assert.NoError(t, err)
+		}
+	}()
+
+	display := func(n int) string {
+		return now.AddDate(0, 0, n).Format(`02.01.2006`)
+	}
+	tbl := []struct {
+		search string
+		want   []string
+	}{
+		{"filter22", []string{a, b, c, d}},
+		{"filter22 tag:work", []string{a, b}},
+		{"filter22 tag:urgent tag:work", []string{b}},
+		{"filter22 tag:wor", nil},
+		{"filter22 repeating:true", []string{a}},
+		{"filter22 repeating=false", []string{b, c, d}},
+		{"filter22 overdue:true", []string{d}},
+		{"filter22 overdue:false", []string{a, b, c}},
+		{"filter22 priority>=2", []string{a, b}},
+		{"filter22 priority:0", []string{c, d}},
+		{"filter22 from:" + display(1) + " to:" + day(1), []string{b, c}},
+		{"filter22 tag:work due<" + display(10), []string{b}},
+		{"filter22 date>=" + day(1), []string{a, b, c}},
+		{"filter22 " + display(1), []string{b, c}},
+		{`filter22 "с командой"`, []string{a}},
+		{"filter22 команд", []string{a}},
+		{"filter22 с командой", []string{a}},
+	}
+	for _, v := range tbl {
+		got, total := getPages(t, url.Values{"search": {v.search}, "sort": {"created"}, "status": {"all"}})
+		assert.Equal(t, v.want, got, v.search)
+		assert.Equal(t, len(v.want), total, v.search)
+	}
+
+	for _, search := range []string{
+		"filter22 due<abc",
+		"filter22 from:",
+		"filter22 from<" + day(1),
+		"filter22 repeating:maybe",
+		"filter22 tag>work",
+		"filter22 priority:high",
+	} {
+		status, ret := requestError(t, "api/tasks?search="+url.QueryEscape(search), nil, http.MethodGet)
+		assert.Equal(t, http.StatusBadRequest, status, search)
+		assert.Equal(t, "invalid_request", ret.Code, search)
+		assert.Equal(t, "search", ret.Field, search)
+	}
+
+	for _, tags := range []string{"a b", "work,", "work,,home", "#work"} {
+		status, ret := requestError(t, "api/task", map[string]any{"title": "Теги", "tags": tags}, http.MethodPost)
+		assert.Equal(t, http.StatusBadRequest, status, tags)
+		assert.Equal(t, "invalid_tags", ret.Code, tags)
+	}
+}
+
+// TestFilterLiteral проверяет, что символы "%", "_" и "\" в тексте запроса без слов ищутся буквально,
+// а не как спецсимволы шаблона LIKE.
+func TestFilterLiteral(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	id := addTaskFields(t, map[string]any{"title": "Скидка 50%_", "comment": `C:\tmp`})
+	defer func() {
+		_, err := db.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+		assert.NoError(t, err)
+	}()
+
+	for _, v := range []struct {
+		search string
+		want   []string
+	}{
+		{"%_", []string{id}},
+		{`:\`, []string{id}},
+		{"_%", nil},
+		{`%\_`, nil},
+	} {
+		got, total := getPages(t, url.Values{"search": {v.search}, "sort": {"created"}, "status": {"all"}})
+		assert.Equal(t, v.want, got, v.search)
+		assert.Equal(t, len(v.want), total, v.search)
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/internal/models"
+)
+
+// searchTasks ищет задачи по запросу search с сортировкой sort и возвращает найденные задачи.
+func searchTasks(t *testing.T, search, sort string) []map[string]string {
+	body, err := requestJSON("api/tasks?"+url.Values{"search": {search}, "sort": {sort}}.Encode(), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var page taskPage
+	assert.NoError(t, json.Unmarshal(body, &page), string(body))
+	return page.Tasks
+}
+
+func TestFullText(t *testing.T) {
+	title := addTaskFields(t, map[string]any{"title": "Квазисозвон <в 16:00>", "comment": "обсудить план"})
+	comment := addTaskFields(t, map[string]any{"title": "Подготовка",
+		"comment": "перед квазисозвоном собрать вопросы"})
+	other := addTaskFields(t, map[string]any{"title": "Квазиотчёт"})
+	defer func() {
+		for _, id := range []string{title, comment} {
+			_, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+			assert.NoError(t, err)
+		}
+	}()
+
+	ids := func(tasks []map[string]string) []string {
+		var ret []string
+		for _, task := range tasks {
+			ret = append(ret, task["id"])
+		}
+		return ret
+	}
+
+	tasks := searchTasks(t, "квазисозвон", "relevance")
+	assert.Equal(t, []string{title, comment}, ids(tasks))
+	if assert.Len(t, tasks, 2) {
+		assert.Equal(t, "<mark>Квазисозвон</mark> &lt;в 16:00&gt;", tasks[0]["snippet"])
+		assert.Equal(t, "перед <mark>квазисозвоном</mark> собрать вопросы", tasks[1]["snippet"])
+	}
+	assert.Equal(t, []string{title, comment}, ids(searchTasks(t, "КВАЗИСОЗВ", "relevance")))
+	assert.Equal(t, []string{title}, ids(searchTasks(t, "квазисозвон план", "relevance")))
+	assert.Equal(t, []string{comment}, ids(searchTasks(t, `"перед квазисозвоном"`, "relevance")))
+	assert.Len(t, searchTasks(t, "квазисозвонить", "relevance"), 0)
+	assert.Equal(t, []string{title, comment, other}, ids(searchTasks(t, "кваз", "created")))
+
+	_, err := postJSON("api/task", map[string]any{"id": comment, "title": "Подготовка", "comment": "собрать вопросы",
+		"date": serverNow(t).Format(`20060102`)}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{title}, ids(searchTasks(t, "квазисозвон", "relevance")))
+	assert.Equal(t, []string{comment}, ids(searchTasks(t, "вопросы", "relevance")))
+
+	_, err = postJSON("api/task?id="+other, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{title}, ids(searchTasks(t, "кваз", "created")))
+}
+
+func TestSearchDiacritics(t *testing.T) {
+	for name, dsn := range storeDSNs(t) {
+		t.Run(name, func(t *testing.T) {
+			store := openStore(t, dsn, serverNow(t))
+			user, err := store.CreateUser(fmt.Sprintf("diacritics%d", time.Now().UnixNano()), "secret")
+			require.NoError(t, err)
+			date := serverNow(t).Format("20060102")
+			accented, err := store.Insert(user.ID, &models.Task{Title: "Встреча в Café Noir", Date: date})
+			require.NoError(t, err)
+			plain, err := store.Insert(user.ID, &models.Task{Title: "Купить зёрна", Comment: "cafe на углу", Date: date})
+			require.NoError(t, err)
+
+			search := func(key string) []models.Task {
+				page, err := store.Search(user.ID, key, models.TaskQuery{Status: models.StatusActive, Limit: 10,
+					Sort: models.SortDate})
+				require.NoError(t, err)
+				return page.Tasks
+			}
+			tasks := search("café")
+			if assert.Len(t, tasks, 1) {
+				assert.Equal(t, strconv.Itoa(accented), tasks[0].ID)
+				assert.Equal(t, "Встреча в <mark>Café</mark> Noir", tasks[0].Snippet)
+			}
+			tasks = search("CAFE")
+			if assert.Len(t, tasks, 1) {
+				assert.Equal(t, strconv.Itoa(plain), tasks[0].ID)
+				assert.Equal(t, "<mark>cafe</mark> на углу", tasks[0].Snippet)
+			}
+			assert.Len(t, search("caf"), 2)
+		})
+	}
+}
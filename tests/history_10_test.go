@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type history struct {
+	ID          string `json:"id"`
+	Completions []struct {
+		Date        string `json:"date"`
+		CompletedAt string `json:"completed_at"`
+		Note        string `json:"note"`
+	} `json:"completions"`
+	Stats struct {
+		Total         int `json:"total"`
+		OnTime        int `json:"on_time"`
+		Adherence     int `json:"adherence"`
+		CurrentStreak int `json:"current_streak"`
+		LongestStreak int `json:"longest_streak"`
+	} `json:"stats"`
+}
+
+func getHistory(t *testing.T, id string) history {
+	body, err := requestJSON("api/task/history?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var h history
+	err = json.Unmarshal(body, &h)
+	assert.NoError(t, err)
+	return h
+}
+
+func TestHistory(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	id := addTask(t, task{
+		title:  "Полить цветы",
+		repeat: "d 3",
+	})
+	assert.Empty(t, getHistory(t, id).Completions)
+
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/done?id="+id, map[string]any{"note": "и кактус"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	h := getHistory(t, id)
+	assert.Equal(t, id, h.ID)
+	if assert.Len(t, h.Completions, 2) {
+		assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), h.Completions[0].Date)
+		assert.Equal(t, "и кактус", h.Completions[0].Note)
+		assert.Equal(t, now.Format(`20060102`), h.Completions[1].Date)
+		assert.Empty(t, h.Completions[1].Note)
+	}
+	assert.Equal(t, 2, h.Stats.Total)
+	assert.Equal(t, 2, h.Stats.OnTime)
+	assert.Equal(t, 100, h.Stats.Adherence)
+	assert.Equal(t, 2, h.Stats.CurrentStreak)
+
+	res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, 'Вынести мусор', '', 'd 7')`,
+		now.AddDate(0, 0, -2).Format(`20060102`))
+	assert.NoError(t, err)
+	lateID, err := res.LastInsertId()
+	assert.NoError(t, err)
+	late := fmt.Sprint(lateID)
+
+	ret, err = postJSON("api/task/done?id="+late+"&note="+url.QueryEscape("с опозданием"), nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	h = getHistory(t, late)
+	assert.Equal(t, 1, h.Stats.Total)
+	assert.Equal(t, 0, h.Stats.OnTime)
+	assert.Equal(t, 0, h.Stats.Adherence)
+	assert.Equal(t, 0, h.Stats.CurrentStreak)
+
+	ret, err = postJSON("api/task?id="+late, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	body, err := requestJSON("api/task/history?id="+late, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string]any
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+	assert.Contains(t, m, "error")
+}
+
+// TestHistoryAdherence проверяет, что доля выполненных вовремя считается от числа повторений по правилу задачи,
+// а не от числа записей журнала: неотмеченные повторения её снижают, даты-исключения - нет.
+func TestHistoryAdherence(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	day := func(n int) string {
+		return now.AddDate(0, 0, n).Format(`20060102`)
+	}
+	res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, 'Зарядка', '', 'd 1')`, day(1))
+	assert.NoError(t, err)
+	taskID, err := res.LastInsertId()
+	assert.NoError(t, err)
+	id := fmt.Sprint(taskID)
+	defer func() {
+		_, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+	}()
+	// вовремя отмечены три повторения из пяти за последние пять дней
+	for _, n := range []int{-4, -2, 0} {
+		date, err := time.ParseInLocation(`20060102`, day(n), time.Local)
+		assert.NoError(t, err)
+		_, err = db.Exec(`INSERT INTO task_completions (task_id, user_id, occurrence_date, completed_at, note)
+		VALUES (?, 1, ?, ?, '')`, taskID, day(n), date.Add(9*time.Hour).Unix())
+		assert.NoError(t, err)
+	}
+	h := getHistory(t, id)
+	assert.Equal(t, 3, h.Stats.Total)
+	assert.Equal(t, 3, h.Stats.OnTime)
+	assert.Equal(t, 60, h.Stats.Adherence)
+
+	_, err = db.Exec(`INSERT INTO task_exceptions (task_id, user_id, date) VALUES (?, 1, ?)`, taskID, day(-3))
+	assert.NoError(t, err)
+	h = getHistory(t, id)
+	assert.Equal(t, 75, h.Stats.Adherence)
+}
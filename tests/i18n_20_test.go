@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// requestLang выполняет запрос с заголовком Accept-Language и возвращает тело ответа.
+func requestLang(t *testing.T, apipath string, values map[string]any, method, acceptLanguage string) []byte {
+	var data []byte
+	if len(values) > 0 {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if len(acceptLanguage) > 0 {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return body
+}
+
+func TestLocalizedErrors(t *testing.T) {
+	tbl := []struct {
+		path     string
+		values   map[string]any
+		method   string
+		language string
+		message  string
+	}{
+		{"api/task", map[string]any{"title": ""}, http.MethodPost, "", "не указано название задачи"},
+		{"api/task", map[string]any{"title": ""}, http.MethodPost, "en-US,en;q=0.9", "task title is empty"},
+		{"api/task", map[string]any{"title": ""}, http.MethodPost, "fr, ru;q=0.5, en;q=0.3", "не указано название задачи"},
+		{"api/task", map[string]any{"title": ""}, http.MethodPost, "de", "не указано название задачи"},
+		{"api/task", map[string]any{"title": "Задача", "date": "2024"}, http.MethodPost, "en", "invalid date format 2024"},
+		{"api/task", map[string]any{"title": "Задача", "repeat": "RRULE:FREQ=DAILY;COUNT=2;UNTIL=20300101"}, http.MethodPost, "en",
+			"RRULE can not have both COUNT and UNTIL"},
+		{"api/task?id=99999999", nil, http.MethodGet, "ru", "задача 99999999: запись не найдена"},
+		{"api/task?id=99999999", nil, http.MethodGet, "en", "task 99999999: no such id"},
+		{"api/nextdate?now=20240126&date=20240126&repeat=k%2034", nil, http.MethodGet, "en", "invalid repeat format"},
+		{"api/nextdate?now=20240126&date=20240126&repeat=k%2034&lang=ru", nil, http.MethodGet, "en", "неверный формат repeat"},
+	}
+	for _, v := range tbl {
+		var ret apiError
+		body := requestLang(t, v.path, v.values, v.method, v.language)
+		assert.NoError(t, json.Unmarshal(body, &ret), string(body))
+		assert.Equal(t, v.message, ret.Error, "%s %s %s", v.method, v.path, v.language)
+	}
+}
+
+func TestLocalizedDescription(t *testing.T) {
+	tbl := []struct {
+		repeat   string
+		language string
+		want     string
+	}{
+		{"d 3", "ru", "каждые 3 дня"},
+		{"d 3", "en", "every 3 days"},
+		{"w 1,5", "en", "weekly, on Mondays and Fridays"},
+		{"m 1,15,-1", "en", "monthly, on the 1st and 15th and the last day of the month"},
+		{"mw -1:5 12", "en", "monthly, the last Friday, in December"},
+		{"bd 1", "en", "every business day"},
+		{"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=1", "en", "every 2 weeks, 1 time in total"},
+	}
+	for _, v := range tbl {
+		body := requestLang(t, "api/occurrences?date=20240101&count=1&repeat="+url.QueryEscape(v.repeat), nil, http.MethodGet, v.language)
+		var ret struct {
+			Description string `json:"description"`
+		}
+		assert.NoError(t, json.Unmarshal(body, &ret), string(body))
+		assert.Equal(t, v.want, ret.Description, v.repeat)
+	}
+}
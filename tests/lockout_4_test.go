@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go_final_project/internal/handlers"
+	"go_final_project/internal/models"
+	"go_final_project/internal/utils"
+)
+
+// lockoutServer запускает в процессе теста обработчик /api/signin с отдельным хранилищем store.
+// Блокировки проверяются не на общем сервере, чтобы неудачные попытки не заблокировали адрес, с которого
+// выполняются остальные тесты.
+func lockoutServer(t *testing.T, store models.Store) *httptest.Server {
+	handler := handlers.NewHandler(store, utils.SystemClock{}, zap.NewNop().Sugar())
+	server := httptest.NewServer(http.HandlerFunc(handler.Authentication))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// signinStatus выполняет вход на сервере server и возвращает код состояния и заголовок Retry-After.
+func signinStatus(t *testing.T, server *httptest.Server, login, password string) (int, string) {
+	data, err := json.Marshal(map[string]string{"login": login, "password": password})
+	require.NoError(t, err)
+	resp, err := http.Post(server.URL, "application/json", bytes.NewReader(data))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.StatusCode, resp.Header.Get("Retry-After")
+}
+
+func TestSigninLockout(t *testing.T) {
+	t.Setenv("TODO_PASSWORD", "shared-password")
+	t.Setenv("TODO_LOGIN_PERSIST", "")
+	store := models.NewMemoryStore(utils.SystemClock{}, zap.NewNop().Sugar())
+	_, err := store.CreateUser("alice", "alice-password")
+	require.NoError(t, err)
+	server := lockoutServer(t, store)
+
+	for i := 0; i < 5; i++ {
+		status, _ := signinStatus(t, server, "alice", "wrong-password")
+		assert.Equal(t, http.StatusUnauthorized, status, "попытка %d", i+1)
+	}
+	status, retry := signinStatus(t, server, "alice", "wrong-password")
+	assert.Equal(t, http.StatusTooManyRequests, status)
+	assert.Equal(t, "30", retry)
+	status, _ = signinStatus(t, server, "alice", "alice-password")
+	assert.Equal(t, http.StatusTooManyRequests, status)
+
+	// блокируется только пара адреса и логина: другие логины и общий пароль с того же адреса доступны
+	status, _ = signinStatus(t, server, "bob", "wrong-password")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = signinStatus(t, server, "", "shared-password")
+	assert.Equal(t, http.StatusOK, status)
+
+	// после 20 неудачных попыток под любыми логинами блокируется весь адрес
+	for i := 7; i <= 20; i++ {
+		status, _ = signinStatus(t, server, fmt.Sprintf("user%d", i), "wrong-password")
+		assert.Equal(t, http.StatusUnauthorized, status, "попытка %d", i)
+	}
+	status, retry = signinStatus(t, server, "", "shared-password")
+	assert.Equal(t, http.StatusTooManyRequests, status)
+	assert.NotEmpty(t, retry)
+}
+
+func TestSigninLockoutPersist(t *testing.T) {
+	t.Setenv("TODO_PASSWORD", "shared-password")
+	t.Setenv("TODO_LOGIN_PERSIST", "true")
+	store := openStore(t, "sqlite://"+filepath.Join(t.TempDir(), "lockout.db"), time.Now())
+
+	server := lockoutServer(t, store)
+	for i := 0; i < 5; i++ {
+		status, _ := signinStatus(t, server, "", "wrong-password")
+		assert.Equal(t, http.StatusUnauthorized, status)
+	}
+
+	// счётчики загружаются из базы данных при создании обработчика, как после перезапуска сервера
+	restarted := lockoutServer(t, store)
+	status, retry := signinStatus(t, restarted, "", "shared-password")
+	assert.Equal(t, http.StatusTooManyRequests, status)
+	assert.NotEmpty(t, retry)
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"database/sql"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go_final_project/internal/models"
+	"go_final_project/internal/utils"
+)
+
+// migrateDB открывает файл SQLite path без применения миграций.
+func migrateDB(t *testing.T, path string) *models.DBConnection {
+	store, closeStore, err := models.OpenStore("sqlite://"+path, "pgx", utils.SystemClock{}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	t.Cleanup(func() { closeStore() })
+	return store.(*models.DBConnection)
+}
+
+// appliedCount возвращает число применённых миграций и проверяет, что они применены без пропусков.
+func appliedCount(t *testing.T, db *models.DBConnection) int {
+	statuses, err := db.MigrationStatus()
+	require.NoError(t, err)
+	count := 0
+	for _, status := range statuses {
+		if status.Applied {
+			assert.Equal(t, count, status.Version-1, "миграция %d применена раньше предыдущей", status.Version)
+			count++
+		}
+	}
+	return count
+}
+
+func TestMigrations(t *testing.T) {
+	db := migrateDB(t, filepath.Join(t.TempDir(), "migrate.db"))
+	statuses, err := db.MigrationStatus()
+	require.NoError(t, err)
+	total := len(statuses)
+	require.NotZero(t, total)
+	assert.Zero(t, appliedCount(t, db))
+
+	require.NoError(t, db.Migrate())
+	assert.Equal(t, total, appliedCount(t, db))
+	// повторный запуск ничего не меняет
+	require.NoError(t, db.Migrate())
+	assert.Equal(t, total, appliedCount(t, db))
+
+	id, err := db.Insert(models.DefaultUserID, &models.Task{Title: "Пережить откат", Date: "20300115", Repeat: "d 3"})
+	require.NoError(t, err)
+
+	require.NoError(t, db.MigrateDown())
+	assert.Equal(t, total-1, appliedCount(t, db))
+	require.NoError(t, db.Migrate())
+	assert.Equal(t, total, appliedCount(t, db))
+	task, err := db.GetTask(models.DefaultUserID, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Пережить откат", task.Title)
+
+	// все миграции откатываются до пустой схемы и применяются заново
+	for i := total; i > 0; i-- {
+		require.NoError(t, db.MigrateDown(), "откат до версии %d", i-1)
+		assert.Equal(t, i-1, appliedCount(t, db))
+	}
+	assert.Error(t, db.MigrateDown())
+	require.NoError(t, db.Migrate())
+	assert.Equal(t, total, appliedCount(t, db))
+}
+
+func TestMigrateLegacyDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	legacy, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	// схема базы данных до появления миграций
+	_, err = legacy.Exec(`CREATE TABLE scheduler (
+		id      INTEGER PRIMARY KEY AUTOINCREMENT,
+		date    CHAR(8) NOT NULL DEFAULT '',
+		title   VARCHAR(128) NOT NULL DEFAULT '',
+		comment TEXT,
+		repeat  VARCHAR(128) NOT NULL DEFAULT ''
+		);
+		CREATE INDEX taks_date ON scheduler (date);`)
+	require.NoError(t, err)
+	res, err := legacy.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES ('20300115', 'Старая задача', 'Комментарий', 'd 7')`)
+	require.NoError(t, err)
+	id, err := res.LastInsertId()
+	require.NoError(t, err)
+	require.NoError(t, legacy.Close())
+
+	db := migrateDB(t, path)
+	require.NoError(t, db.Migrate())
+	task, err := db.GetTask(models.DefaultUserID, int(id))
+	require.NoError(t, err)
+	assert.Equal(t, "20300115", task.Date)
+	assert.Equal(t, "Старая задача", task.Title)
+	assert.Equal(t, "Комментарий", task.Comment)
+	assert.Equal(t, "d 7", task.Repeat)
+
+	page, err := db.Search(models.DefaultUserID, "старая", models.TaskQuery{Status: models.StatusActive, Limit: 10, Sort: models.SortDate})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	assert.Equal(t, task.ID, page.Tasks[0].ID)
+}
+
+func TestMigrateCommand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("сборка сервера пропускается в режиме -short")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "scheduler")
+	build := exec.Command("go", "build", "-o", binary, ".")
+	build.Dir = ".."
+	output, err := build.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	dbfile := filepath.Join(dir, "cli.db")
+	migrate := func(args ...string) (string, error) {
+		cmd := exec.Command(binary, append([]string{"migrate"}, args...)...)
+		cmd.Env = append(os.Environ(), "TODO_DSN=", "TODO_DBFILE="+dbfile)
+		var stdout strings.Builder
+		cmd.Stdout = &stdout
+		err := cmd.Run()
+		return stdout.String(), err
+	}
+	// statusLines возвращает число строк вывода "migrate status" с отметкой mark
+	statusLines := func(mark string) int {
+		out, err := migrate("status")
+		require.NoError(t, err)
+		count := 0
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if strings.Contains(line, mark) {
+				count++
+			}
+		}
+		return count
+	}
+
+	total := statusLines("pending")
+	require.NotZero(t, total)
+	assert.Zero(t, statusLines("applied"))
+
+	_, err = migrate("up")
+	require.NoError(t, err)
+	assert.Equal(t, total, statusLines("applied "+time.Now().Format("2006")))
+	assert.Zero(t, statusLines("pending"))
+
+	_, err = migrate("down")
+	require.NoError(t, err)
+	assert.Equal(t, total-1, statusLines("applied"))
+	out, err := migrate("status")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Contains(t, lines[len(lines)-1], "pending")
+
+	// статус выводится и без подкоманды
+	out, err = migrate()
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(out), "\n"), total)
+
+	_, err = migrate("sideways")
+	assert.Error(t, err)
+}
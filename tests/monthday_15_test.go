@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNextDateMonthDay проверяет правило "m" на границах месяцев: последний и предпоследний дни
+// в месяцах разной длины, числа, которых нет в коротких месяцах, и переход через конец года.
+// Дата задачи совпадает с now, как при выполнении задачи в день повторения.
+func TestNextDateMonthDay(t *testing.T) {
+	if !FullNextDate {
+		return
+	}
+	tbl := []struct {
+		now    string
+		date   string
+		repeat string
+		want   string
+	}{
+		{"20240210", "20240210", "m -1", "20240229"},
+		{"20230210", "20230210", "m -1", "20230228"},
+		{"20240210", "20240210", "m -2", "20240228"},
+		{"20230210", "20230210", "m -2", "20230227"},
+		{"20240405", "20240405", "m -1", "20240430"},
+		{"20240131", "20240131", "m -1", "20240229"},
+		{"20240130", "20240130", "m -2", "20240228"},
+		{"20231231", "20231231", "m -1", "20240131"},
+		{"20231231", "20231231", "m -2,-1", "20240130"},
+		{"20240101", "20230101", "m -1 2", "20240229"},
+		{"20240401", "20240401", "m 31", "20240531"},
+		{"20240201", "20240201", "m 31", "20240331"},
+		{"20240131", "20240131", "m 31", "20240331"},
+		{"20240131", "20240131", "m 30", "20240330"},
+		{"20230201", "20230201", "m 29", "20230329"},
+		{"20240201", "20240201", "m 29", "20240229"},
+		{"20240101", "20240101", "m 31 4,6", ""},
+		{"20231101", "20231115", "m 15", "20231215"},
+		{"20231105", "20231105", "m 1,-1", "20231130"},
+		{"20231130", "20231130", "m 1,-1", "20231201"},
+	}
+	for _, v := range tbl {
+		urlPath := fmt.Sprintf("api/nextdate?now=%s&date=%s&repeat=%s", v.now, v.date, url.QueryEscape(v.repeat))
+		get, err := getBody(urlPath)
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(get))
+		_, err = time.Parse("20060102", next)
+		if err != nil && len(v.want) == 0 {
+			continue
+		}
+		assert.Equal(t, v.want, next, `{%q, %q, %q, %q}`, v.now, v.date, v.repeat, v.want)
+	}
+}
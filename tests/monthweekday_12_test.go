@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextDateMonthWeekday(t *testing.T) {
+	tbl := []nextDate{
+		{"20240101", "mw 1:1", "20240205"},
+		{"20240101", "mw 3:4,1:1", "20240205"},
+		{"20240101", "mw 3:4", "20240215"},
+		{"20240101", "mw -1:5", "20240223"},
+		{"20240101", "mw -1:7 12", "20241229"},
+		{"20240101", "mw 5:4", "20240229"},
+		{"20240301", "mw 2:2 3,9", "20240312"},
+		{"20240101", "mw", ""},
+		{"20240101", "mw 1", ""},
+		{"20240101", "mw 0:1", ""},
+		{"20240101", "mw 6:1", ""},
+		{"20240101", "mw 1:8", ""},
+		{"20240101", "mw 1:1 13", ""},
+	}
+	for _, v := range tbl {
+		urlPath := fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s",
+			url.QueryEscape(v.date), url.QueryEscape(v.repeat))
+		get, err := getBody(urlPath)
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(get))
+		_, err = time.Parse("20060102", next)
+		if err != nil && len(v.want) == 0 {
+			continue
+		}
+		assert.Equal(t, v.want, next, `{%q, %q, %q}`, v.date, v.repeat, v.want)
+	}
+}
+
+func TestAddTaskMonthWeekday(t *testing.T) {
+	ret, err := postJSON("api/task", map[string]any{
+		"title":  "Планёрка",
+		"repeat": "mw 1:8",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	id := addTask(t, task{
+		title:  "Планёрка",
+		repeat: "mw 1:1,-1:5",
+	})
+	assert.NotEmpty(t, id)
+}
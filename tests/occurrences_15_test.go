@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOccurrences(t *testing.T) {
+	type occurrences struct {
+		Repeat      string   `json:"repeat"`
+		Description string   `json:"description"`
+		Dates       []string `json:"dates"`
+		Error       string   `json:"error"`
+	}
+	tbl := []struct {
+		date        string
+		repeat      string
+		count       string
+		description string
+		dates       []string
+	}{
+		{"20240126", "d 1", "3", "ежедневно", []string{"20240126", "20240127", "20240128"}},
+		{"20240101", "d 7", "3", "каждые 7 дней", []string{"20240129", "20240205", "20240212"}},
+		{"20240126", "w 1,4", "4", "еженедельно, по понедельникам и четвергам",
+			[]string{"20240129", "20240201", "20240205", "20240208"}},
+		{"20240101", "m -1,-2", "6", "ежемесячно, последний и предпоследний день месяца",
+			[]string{"20240130", "20240131", "20240228", "20240229", "20240330", "20240331"}},
+		{"20240126", "m 1,15 2,8", "", "ежемесячно, 1-е и 15-е числа, в феврале и августе",
+			[]string{"20240201", "20240215", "20240801", "20240815", "20250201", "20250215",
+				"20250801", "20250815", "20260201", "20260215"}},
+		{"20240126", "mw -1:5 12", "2", "ежемесячно, последняя пятница, в декабре", []string{"20241227", "20251226"}},
+		{"20240115", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=4", "10",
+			"каждые 2 недели, по понедельникам и средам, всего 4 раза", []string{"20240129", "20240131"}},
+	}
+	for _, v := range tbl {
+		query := url.Values{"date": {v.date}, "repeat": {v.repeat}, "from": {"20240126"}, "count": {v.count}}
+		body, err := requestJSON("api/occurrences?"+query.Encode(), nil, http.MethodGet)
+		assert.NoError(t, err)
+		var ret occurrences
+		err = json.Unmarshal(body, &ret)
+		assert.NoError(t, err)
+		assert.Empty(t, ret.Error, v.repeat)
+		assert.Equal(t, v.repeat, ret.Repeat)
+		assert.Equal(t, v.description, ret.Description)
+		assert.Equal(t, v.dates, ret.Dates, v.repeat)
+	}
+
+	for _, query := range []string{"repeat=x", "repeat=d+1&count=1000", "repeat=d+1&from=2024"} {
+		ret, err := postJSON("api/occurrences?"+query, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Contains(t, ret, "error", query)
+	}
+}
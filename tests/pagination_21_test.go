@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type taskPage struct {
+	Tasks      []map[string]string `json:"tasks"`
+	NextCursor string              `json:"next_cursor"`
+	Total      int                 `json:"total"`
+}
+
+// getPages запрашивает все страницы списка задач по ссылкам next_cursor и возвращает идентификаторы задач по порядку.
+func getPages(t *testing.T, params url.Values) ([]string, int) {
+	var ids []string
+	var total int
+	for pages := 0; pages < 10; pages++ {
+		body, err := requestJSON("api/tasks?"+params.Encode(), nil, http.MethodGet)
+		assert.NoError(t, err)
+		var page taskPage
+		if !assert.NoError(t, json.Unmarshal(body, &page), string(body)) {
+			return nil, 0
+		}
+		total = page.Total
+		for _, task := range page.Tasks {
+			ids = append(ids, task["id"])
+		}
+		if page.NextCursor == "" {
+			return ids, total
+		}
+		params.Set("cursor", page.NextCursor)
+	}
+	t.Fatal("too many pages")
+	return nil, 0
+}
+
+func TestPagination(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	day := func(n int) string {
+		return now.AddDate(0, 0, n).Format(`20060102`)
+	}
+	// Задачи в порядке создания.
+	ids := []string{
+		addTaskFields(t, map[string]any{"title": "Пагинация В", "date": day(3), "comment": "pagination", "priority": "1"}),
+		addTaskFields(t, map[string]any{"title": "Пагинация А", "date": day(1), "comment": "pagination", "priority": "3"}),
+		addTaskFields(t, map[string]any{"title": "Пагинация Д", "date": day(2), "comment": "pagination"}),
+		addTaskFields(t, map[string]any{"title": "Пагинация Б", "date": day(1), "comment": "pagination", "priority": "1"}),
+		addTaskFields(t, map[string]any{"title": "Пагинация Г", "date": day(1), "comment": "pagination", "time": "09:00"}),
+	}
+	defer func() {
+		for _, id := range ids {
+			_, err := db.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+			assert.NoError(t, err)
+		}
+	}()
+	v, a, d, b, g := ids[0], ids[1], ids[2], ids[3], ids[4]
+
+	tbl := []struct {
+		sort  string
+		order string
+		want  []string
+	}{
+		{"", "", []string{a, b, g, d, v}},
+		{"date", "desc", []string{v, d, g, b, a}},
+		{"title", "", []string{a, b, v, g, d}},
+		{"title", "desc", []string{d, g, v, b, a}},
+		{"created", "", []string{v, a, d, b, g}},
+		{"created", "desc", []string{g, b, d, a, v}},
+		{"priority", "desc", []string{a, v, b, d, g}},
+	}
+	for _, limit := range []string{"2", "5", "50"} {
+		for _, c := range tbl {
+			params := url.Values{"search": {"pagination"}, "limit": {limit}}
+			if c.sort != "" {
+				params.Set("sort", c.sort)
+			}
+			if c.order != "" {
+				params.Set("order", c.order)
+			}
+			got, total := getPages(t, params)
+			assert.Equal(t, c.want, got, "sort=%s order=%s limit=%s", c.sort, c.order, limit)
+			assert.Equal(t, 5, total)
+		}
+	}
+
+	// Без параметров страниц ответ содержит только список задач.
+	body, err := requestJSON("api/tasks?search=pagination", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.NotContains(t, m, "total")
+	assert.NotContains(t, m, "next_cursor")
+
+	// Курсор продолжает список с той же сортировкой, даже если sort и order не указаны.
+	body, err = requestJSON("api/tasks?search=pagination&sort=title&order=desc&limit=2", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var page taskPage
+	assert.NoError(t, json.Unmarshal(body, &page))
+	got, _ := getPages(t, url.Values{"search": {"pagination"}, "cursor": {page.NextCursor}})
+	assert.Equal(t, []string{v, b, a}, got)
+
+	for _, path := range []string{
+		"api/tasks?limit=0",
+		"api/tasks?limit=501",
+		"api/tasks?limit=abc",
+		"api/tasks?sort=unknown",
+		"api/tasks?order=up",
+		"api/tasks?cursor=abc",
+		"api/tasks?sort=date&cursor=" + page.NextCursor,
+	} {
+		status, ret := requestError(t, path, nil, http.MethodGet)
+		assert.Equal(t, http.StatusBadRequest, status, path)
+		assert.Equal(t, "invalid_request", ret.Code, path)
+	}
+
+	status, ret := requestError(t, "api/task", map[string]any{"title": "Пагинация", "priority": "4"}, http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalid_priority", ret.Code)
+	assert.Equal(t, "priority", ret.Field)
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// refresh обменивает токен обновления token на новую пару и возвращает код состояния и выданные токены.
+func refresh(t *testing.T, token string) (int, tokenPair) {
+	status, body := authRequest(t, http.MethodPost, "api/refresh", map[string]any{"refresh_token": token}, nil)
+	var tokens tokenPair
+	require.NoError(t, json.Unmarshal(body, &tokens), string(body))
+	return status, tokens
+}
+
+func TestRefresh(t *testing.T) {
+	password := authPassword(t)
+	first := signin(t, "", password)
+
+	status, second := refresh(t, first.RefreshToken)
+	require.Equal(t, http.StatusOK, status)
+	assert.NotEqual(t, first.Token, second.Token)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	status, _ = authRequest(t, http.MethodGet, "api/tasks", nil, bearer(second.Token))
+	assert.Equal(t, http.StatusOK, status)
+
+	// использованный токен обновления отозван, токен доступа вместо токена обновления не принимается
+	for _, token := range []string{first.RefreshToken, second.Token, ""} {
+		status, _ = refresh(t, token)
+		assert.NotEqual(t, http.StatusOK, status, token)
+	}
+	status, third := refresh(t, second.RefreshToken)
+	assert.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, third.Token)
+}
+
+func TestSignOut(t *testing.T) {
+	password := authPassword(t)
+	tokens := signin(t, "", password)
+	other := signin(t, "", password)
+
+	status, body := authRequest(t, http.MethodPost, "api/signout", map[string]any{"refresh_token": tokens.RefreshToken},
+		bearer(tokens.Token))
+	require.Equal(t, http.StatusOK, status, string(body))
+
+	status, body = authRequest(t, http.MethodGet, "api/tasks", nil, bearer(tokens.Token))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	var ret apiError
+	assert.NoError(t, json.Unmarshal(body, &ret))
+	assert.Equal(t, "unauthorized", ret.Code)
+	status, _ = refresh(t, tokens.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// отзываются только переданные токены
+	status, _ = authRequest(t, http.MethodGet, "api/tasks", nil, bearer(other.Token))
+	assert.Equal(t, http.StatusOK, status)
+
+	// повторный выход с отозванным токеном не считается ошибкой
+	status, _ = authRequest(t, http.MethodPost, "api/signout", nil, bearer(tokens.Token))
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addTaskFields(t *testing.T, fields map[string]any) string {
+	ret, err := postJSON("api/task", fields, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotNil(t, ret["id"])
+	return fmt.Sprint(ret["id"])
+}
+
+func TestRepeatEnd(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	for _, fields := range []map[string]any{
+		{"title": "Без правила", "repeat_until": now.Format(`20060102`)},
+		{"title": "Без правила", "repeat_count": "3"},
+		{"title": "Неверная дата", "repeat": "d 1", "repeat_until": "31.12.2026"},
+		{"title": "Отрицательное число", "repeat": "d 1", "repeat_count": "-1"},
+		{"title": "Раньше даты", "repeat": "d 1", "date": now.AddDate(0, 0, 5).Format(`20060102`),
+			"repeat_until": now.AddDate(0, 0, 1).Format(`20060102`)},
+	} {
+		ret, err := postJSON("api/task", fields, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Contains(t, ret, "error", fields)
+	}
+
+	id := addTaskFields(t, map[string]any{
+		"title":        "Три тренировки",
+		"repeat":       "d 1",
+		"repeat_count": "2",
+	})
+	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), task.Date)
+	assert.Equal(t, int64(1), task.RepeatCount)
+	assert.Zero(t, task.CompletedAt)
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.NotZero(t, task.CompletedAt)
+
+	until := now.AddDate(0, 0, 3).Format(`20060102`)
+	id = addTaskFields(t, map[string]any{
+		"title":        "До пятницы",
+		"repeat":       "d 2",
+		"repeat_until": until,
+	})
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 2).Format(`20060102`), task.Date)
+	assert.Equal(t, until, task.RepeatUntil)
+	assert.Zero(t, task.CompletedAt)
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 2).Format(`20060102`), task.Date)
+	assert.NotZero(t, task.CompletedAt)
+}
+
+func TestEditKeepsOmittedFields(t *testing.T) {
+	now := serverNow(t)
+	date := now.AddDate(0, 0, 2).Format(`20060102`)
+	until := now.AddDate(0, 0, 30).Format(`20060102`)
+	id := addTaskFields(t, map[string]any{
+		"title":        "Полив цветов",
+		"date":         date,
+		"repeat":       "d 3",
+		"repeat_until": until,
+		"repeat_count": "4",
+		"roll":         "forward",
+		"time":         "09:30",
+		"timezone":     "Europe/Moscow",
+		"priority":     "2",
+		"tags":         "home",
+	})
+	defer func() {
+		_, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+	}()
+	edit := func(fields map[string]any) map[string]string {
+		fields["id"] = id
+		ret, err := postJSON("api/task", fields, http.MethodPut)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+		return getTaskFields(t, id)
+	}
+
+	task := edit(map[string]any{"title": "Полив всех цветов", "date": date, "repeat": "d 3"})
+	assert.Equal(t, "Полив всех цветов", task["title"])
+	assert.Equal(t, "4", task["repeat_count"])
+	assert.Equal(t, until, task["repeat_until"])
+	assert.Equal(t, "forward", task["roll"])
+	assert.Equal(t, "09:30", task["time"])
+	assert.Equal(t, "Europe/Moscow", task["timezone"])
+	assert.Equal(t, "2", task["priority"])
+	assert.Equal(t, "home", task["tags"])
+
+	// переданные поля заменяются, в том числе пустыми значениями
+	task = edit(map[string]any{"title": "Полив всех цветов", "date": date, "repeat": "d 3", "repeat_count": "0",
+		"tags": "", "time": "10:00"})
+	assert.Empty(t, task["repeat_count"])
+	assert.Equal(t, until, task["repeat_until"])
+	assert.Empty(t, task["tags"])
+	assert.Equal(t, "10:00", task["time"])
+
+	// без правила повторения окончание повторений не сохраняется
+	task = edit(map[string]any{"title": "Полив всех цветов", "date": date})
+	assert.Empty(t, task["repeat"])
+	assert.Empty(t, task["repeat_until"])
+	assert.Equal(t, "Europe/Moscow", task["timezone"])
+}
+
+// TestNumericFields проверяет, что числовые поля задачи принимаются не только строками, в которых их возвращает API,
+// но и числами JSON.
+func TestNumericFields(t *testing.T) {
+	date := serverNow(t).AddDate(0, 0, 2).Format(`20060102`)
+	id := addTaskFields(t, map[string]any{
+		"title":        "Числовые поля",
+		"date":         date,
+		"repeat":       "d 2",
+		"repeat_count": 3,
+		"priority":     2,
+	})
+	defer func() {
+		_, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+		assert.NoError(t, err)
+	}()
+	task := getTaskFields(t, id)
+	assert.Equal(t, "3", task["repeat_count"])
+	assert.Equal(t, "2", task["priority"])
+
+	version, err := strconv.Atoi(task["version"])
+	require.NoError(t, err)
+	ret, err := postJSON("api/task", map[string]any{"id": id, "title": "Числовые поля", "date": date, "repeat": "d 2",
+		"version": version, "priority": 1}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	task = getTaskFields(t, id)
+	assert.Equal(t, "1", task["priority"])
+	assert.Equal(t, "3", task["repeat_count"])
+	assert.Equal(t, strconv.Itoa(version+1), task["version"])
+
+	// устаревшая версия отклоняется и в виде числа
+	status, apiErr := requestError(t, "api/task", map[string]any{"id": id, "title": "Числовые поля", "date": date,
+		"version": version}, http.MethodPut)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "conflict", apiErr.Code)
+
+	ret, err = postJSON("api/task", map[string]any{"id": id, "title": "Числовые поля", "date": date, "repeat": "d 2",
+		"priority": "", "repeat_count": 0}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	task = getTaskFields(t, id)
+	assert.Empty(t, task["priority"])
+	assert.Empty(t, task["repeat_count"])
+
+	for _, fields := range []map[string]any{
+		{"title": "Дробный приоритет", "priority": 1.5},
+		{"title": "Приоритет словом", "priority": "high"},
+		{"title": "Логическое число", "repeat": "d 1", "repeat_count": true},
+	} {
+		ret, err := postJSON("api/task", fields, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Contains(t, ret, "error", fields)
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextDateRRule(t *testing.T) {
+	tbl := []nextDate{
+		{"20240126", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "20240205"},
+		{"20240101", "RRULE:FREQ=MONTHLY;BYDAY=2TU", "20240213"},
+		{"20240101", "RRULE:FREQ=MONTHLY;BYDAY=-1FR", "20240223"},
+		{"20240101", "RRULE:FREQ=MONTHLY;BYMONTHDAY=-1", "20240131"},
+		{"20240101", "RRULE:FREQ=DAILY;INTERVAL=10", "20240131"},
+		{"20240101", "RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", "20241128"},
+		{"20240229", "RRULE:FREQ=YEARLY", "20280229"},
+		{"20240101", "RRULE:FREQ=DAILY;UNTIL=20240127", "20240127"},
+		{"20240101", "rrule:freq=weekly;byday=sa,su", "20240127"},
+		{"20240101", "RRULE:FREQ=DAILY;COUNT=5", ""},
+		{"20240101", "RRULE:FREQ=DAILY;UNTIL=20240126", ""},
+		{"20240101", "RRULE:FREQ=WEEKLY;BYDAY=2MO", ""},
+		{"20240101", "RRULE:FREQ=HOURLY", ""},
+		{"20240101", "RRULE:INTERVAL=2", ""},
+		{"20240101", "RRULE:FREQ=DAILY;COUNT=2;UNTIL=20240301", ""},
+		{"20240101", "RRULE:FREQ=MONTHLY;BYSETPOS=1", ""},
+	}
+	for _, v := range tbl {
+		urlPath := fmt.Sprintf("api/nextdate?now=20240126&date=%s&repeat=%s",
+			url.QueryEscape(v.date), url.QueryEscape(v.repeat))
+		get, err := getBody(urlPath)
+		assert.NoError(t, err)
+		next := strings.TrimSpace(string(get))
+		_, err = time.Parse("20060102", next)
+		if err != nil && len(v.want) == 0 {
+			continue
+		}
+		assert.Equal(t, v.want, next, `{%q, %q, %q}`, v.date, v.repeat, v.want)
+	}
+}
+
+func TestDoneRRuleCount(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	ret, err := postJSON("api/task", map[string]any{
+		"title":  "Неверное правило",
+		"repeat": "RRULE:FREQ=DAILY;BYHOUR=9",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	now := serverNow(t)
+	id := addTask(t, task{
+		title:  "Курс таблеток",
+		repeat: "RRULE:FREQ=DAILY;COUNT=2",
+	})
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), task.Date)
+	assert.Equal(t, "RRULE:FREQ=DAILY;COUNT=1", task.Repeat)
+	assert.Zero(t, task.CompletedAt)
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.NotZero(t, task.CompletedAt)
+}
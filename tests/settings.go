@@ -5,3 +5,4 @@ var DBFile = "../scheduler.db"
 var FullNextDate = true
 var Search = true
 var Token = ``
+var FakeNow = ``
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getSkips(t *testing.T, id string) []string {
+	body, err := requestJSON("api/task/skip?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var skips struct {
+		ID    string   `json:"id"`
+		Dates []string `json:"dates"`
+	}
+	err = json.Unmarshal(body, &skips)
+	assert.NoError(t, err)
+	assert.Equal(t, id, skips.ID)
+	return skips.Dates
+}
+
+func TestSkip(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	id := addTask(t, task{
+		title:  "Тренировка",
+		repeat: "d 2",
+	})
+
+	ret, err := postJSON("api/task/skip?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var stored Task
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 2).Format(`20060102`), stored.Date)
+	assert.Empty(t, getHistory(t, id).Completions)
+
+	skipped := now.AddDate(0, 0, 4).Format(`20060102`)
+	ret, err = postJSON("api/task/skip?id="+id+"&date="+skipped, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Equal(t, []string{now.Format(`20060102`), skipped}, getSkips(t, id))
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 6).Format(`20060102`), stored.Date)
+
+	ret, err = postJSON("api/task/skip?id="+id+"&date="+skipped, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Equal(t, []string{now.Format(`20060102`)}, getSkips(t, id))
+
+	ret, err = postJSON("api/task/skip?id="+id+"&date="+skipped, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+	ret, err = postJSON("api/task/skip?id="+id+"&date=qwerty", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	once := addTask(t, task{
+		title: "Разовая задача",
+	})
+	ret, err = postJSON("api/task/skip?id="+once, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Contains(t, ret, "error")
+
+	_, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	var left int
+	err = db.Get(&left, `SELECT COUNT(*) FROM task_exceptions WHERE task_id=?`, id)
+	assert.NoError(t, err)
+	assert.Zero(t, left)
+}
+
+// TestSkipRepeatEnd проверяет, что пропуск не расходует число оставшихся повторений и не завершает задачу:
+// последнее повторение серии пропустить нельзя.
+func TestSkipRepeatEnd(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	day := func(n int) string {
+		return now.AddDate(0, 0, n).Format(`20060102`)
+	}
+	id := addTaskFields(t, map[string]any{"title": "Последняя тренировка", "repeat": "d 2", "repeat_count": "1"})
+	defer postJSON("api/task?id="+id, nil, http.MethodDelete)
+
+	ret, err := postJSON("api/task/skip?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var stored Task
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, day(2), stored.Date)
+	assert.Equal(t, int64(1), stored.RepeatCount)
+	assert.Zero(t, stored.CompletedAt)
+
+	// выполняется последнее повторение, а не пропущенное
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.NotZero(t, stored.CompletedAt)
+
+	until := addTaskFields(t, map[string]any{"title": "До завтра", "repeat": "d 2", "repeat_until": day(1)})
+	defer postJSON("api/task?id="+until, nil, http.MethodDelete)
+	status, apiErr := requestError(t, "api/task/skip?id="+until, nil, http.MethodPost)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "conflict", apiErr.Code)
+	err = db.Get(&stored, `SELECT * FROM scheduler WHERE id=?`, until)
+	assert.NoError(t, err)
+	assert.Equal(t, day(0), stored.Date)
+	assert.Zero(t, stored.CompletedAt)
+	assert.Empty(t, getSkips(t, until))
+}
@@ -0,0 +1,142 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	_ "modernc.org/sqlite"
+
+	"go_final_project/internal/models"
+	"go_final_project/internal/utils"
+)
+
+// storeDSNs возвращает строки подключения хранилищ, на которых проверяется models.Store: хранилище в памяти,
+// временный файл SQLite и PostgreSQL, если в переменной окружения TODO_TEST_PG_DSN задана строка подключения
+// к базе данных, которую можно изменять.
+func storeDSNs(t *testing.T) map[string]string {
+	dsns := map[string]string{
+		"memory": "memory://",
+		"sqlite": "sqlite://" + filepath.Join(t.TempDir(), "store.db"),
+	}
+	if dsn := os.Getenv("TODO_TEST_PG_DSN"); dsn != "" {
+		dsns["postgres"] = dsn
+	}
+	return dsns
+}
+
+// openStore открывает хранилище с часами, зафиксированными на now, и приводит схему SQL-хранилища к текущей версии.
+func openStore(t *testing.T, dsn string, now time.Time) models.Store {
+	store, closeStore, err := models.OpenStore(dsn, "pgx", utils.FixedClock{At: now}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	t.Cleanup(func() { closeStore() })
+	if db, ok := store.(*models.DBConnection); ok {
+		require.NoError(t, db.Migrate())
+	}
+	return store
+}
+
+func TestStoreBackends(t *testing.T) {
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.Local)
+	for name, dsn := range storeDSNs(t) {
+		t.Run(name, func(t *testing.T) {
+			store := openStore(t, dsn, now)
+			// задачи создаются от имени нового пользователя, чтобы проверка не зависела от данных в базе
+			user, err := store.CreateUser(fmt.Sprintf("store%d", time.Now().UnixNano()), "secret")
+			require.NoError(t, err)
+
+			once := models.Task{Title: "Созвон с командой", Date: "20300115"}
+			onceID, err := store.Insert(user.ID, &once)
+			require.NoError(t, err)
+			weekly := models.Task{Title: "Отчёт", Comment: "еженедельный", Date: "20300112", Repeat: "d 7",
+				RepeatCount: 2, Time: "09:30", Tags: "work"}
+			weeklyID, err := store.Insert(user.ID, &weekly)
+			require.NoError(t, err)
+
+			task, err := store.GetTask(user.ID, onceID)
+			require.NoError(t, err)
+			assert.Equal(t, "20300115", task.Date)
+			assert.Empty(t, task.RepeatUntil)
+			assert.Empty(t, task.Time)
+			task, err = store.GetTask(user.ID, weeklyID)
+			require.NoError(t, err)
+			assert.Equal(t, "09:30", task.Time)
+			assert.Equal(t, 2, task.RepeatCount)
+
+			query := models.TaskQuery{Status: models.StatusActive, Limit: 10, Sort: models.SortDate}
+			page, err := store.GetAll(user.ID, query)
+			require.NoError(t, err)
+			require.Len(t, page.Tasks, 2)
+			assert.Equal(t, 2, page.Total)
+			assert.Equal(t, strconv.Itoa(weeklyID), page.Tasks[0].ID)
+
+			page, err = store.Search(user.ID, "созвон", query)
+			require.NoError(t, err)
+			require.Len(t, page.Tasks, 1)
+			assert.Equal(t, strconv.Itoa(onceID), page.Tasks[0].ID)
+			page, err = store.Search(user.ID, "tag:work", query)
+			require.NoError(t, err)
+			require.Len(t, page.Tasks, 1)
+			assert.Equal(t, strconv.Itoa(weeklyID), page.Tasks[0].ID)
+
+			require.NoError(t, store.Done(user.ID, weeklyID, 0, "20300112", "готово"))
+			task, err = store.GetTask(user.ID, weeklyID)
+			require.NoError(t, err)
+			assert.Equal(t, "20300119", task.Date)
+			assert.Equal(t, 1, task.RepeatCount)
+			assert.ErrorIs(t, store.Done(user.ID, weeklyID, 0, "20300112", ""), models.ErrConflict)
+			history, err := store.History(user.ID, weeklyID)
+			require.NoError(t, err)
+			require.Len(t, history, 1)
+			assert.Equal(t, "20300112", history[0].Date)
+			assert.Equal(t, "готово", history[0].Note)
+
+			require.NoError(t, store.Skip(user.ID, weeklyID, "20300126"))
+			exceptions, err := store.Exceptions(user.ID, weeklyID)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"20300126"}, exceptions)
+
+			between, err := store.Between(user.ID, "20300113", "20300131")
+			require.NoError(t, err)
+			require.Len(t, between, 2)
+			assert.Equal(t, strconv.Itoa(onceID), between[0].ID)
+			assert.True(t, between[1].Exceptions["20300126"])
+
+			// пропуск текущего повторения не расходует последнее оставшееся повторение и не завершает задачу
+			require.NoError(t, store.Skip(user.ID, weeklyID, ""))
+			task, err = store.GetTask(user.ID, weeklyID)
+			require.NoError(t, err)
+			assert.Equal(t, "20300202", task.Date)
+			assert.Equal(t, 1, task.RepeatCount)
+			assert.Empty(t, task.CompletedAt)
+			last := models.Task{Title: "Последний отчёт", Date: "20300112", Repeat: "d 7", RepeatUntil: "20300118"}
+			lastID, err := store.Insert(user.ID, &last)
+			require.NoError(t, err)
+			assert.ErrorIs(t, store.Skip(user.ID, lastID, ""), models.ErrConflict)
+			exceptions, err = store.Exceptions(user.ID, lastID)
+			require.NoError(t, err)
+			assert.Empty(t, exceptions)
+			require.NoError(t, store.Delete(user.ID, lastID))
+
+			require.NoError(t, store.Done(user.ID, onceID, 0, "", ""))
+			task, err = store.GetTask(user.ID, onceID)
+			require.NoError(t, err)
+			assert.NotEmpty(t, task.CompletedAt)
+			assert.ErrorIs(t, store.Done(user.ID, onceID, 0, "", ""), models.ErrConflict)
+			page, err = store.GetAll(user.ID, query)
+			require.NoError(t, err)
+			assert.Equal(t, 1, page.Total)
+
+			require.NoError(t, store.Delete(user.ID, weeklyID))
+			_, err = store.GetTask(user.ID, weeklyID)
+			assert.ErrorIs(t, err, models.ErrNotFound)
+		})
+	}
+}
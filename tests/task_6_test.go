@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"strconv"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,7 +14,7 @@ func TestTask(t *testing.T) {
 	db := openDB(t)
 	defer db.Close()
 
-	now := time.Now()
+	now := serverNow(t)
 
 	task := task{
 		date:    now.Format(`20060102`),
@@ -57,7 +56,7 @@ func TestEditTask(t *testing.T) {
 	db := openDB(t)
 	defer db.Close()
 
-	now := time.Now()
+	now := serverNow(t)
 
 	tsk := task{
 		date:    now.Format(`20060102`),
@@ -116,7 +115,7 @@ func TestEditTask(t *testing.T) {
 		}
 		assert.Equal(t, newVals["comment"], task.Comment)
 		assert.Equal(t, newVals["repeat"], task.Repeat)
-		now := time.Now().Format(`20060102`)
+		now := serverNow(t).Format(`20060102`)
 		if task.Date < now {
 			t.Errorf("Дата не может быть меньше сегодняшней")
 		}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"net/http"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -23,7 +22,7 @@ func TestDone(t *testing.T) {
 	db := openDB(t)
 	defer db.Close()
 
-	now := time.Now()
+	now := serverNow(t)
 	id := addTask(t, task{
 		date:  now.Format(`20060102`),
 		title: "Свести баланс",
@@ -32,7 +31,11 @@ func TestDone(t *testing.T) {
 	ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
 	assert.NoError(t, err)
 	assert.Empty(t, ret)
-	notFoundTask(t, id)
+
+	var done Task
+	err = db.Get(&done, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.NotZero(t, done.CompletedAt)
 
 	id = addTask(t, task{
 		title:  "Проверить работу /api/task/done",
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -42,7 +41,7 @@ func TestTasks(t *testing.T) {
 	db := openDB(t)
 	defer db.Close()
 
-	now := time.Now()
+	now := serverNow(t)
 	_, err := db.Exec("DELETE FROM scheduler")
 	assert.NoError(t, err)
 
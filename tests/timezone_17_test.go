@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getTaskFields(t *testing.T, id string) map[string]string {
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var task map[string]string
+	err = json.Unmarshal(body, &task)
+	assert.NoError(t, err)
+	return task
+}
+
+func TestTaskTimezone(t *testing.T) {
+	for _, fields := range []map[string]any{
+		{"title": "Неверное время", "time": "25:00"},
+		{"title": "Неверное время", "time": "9:30"},
+		{"title": "Неверный пояс", "timezone": "Mars/Olympus"},
+	} {
+		ret, err := postJSON("api/task", fields, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Contains(t, ret, "error", fields)
+	}
+
+	// сегодняшний день вычисляется в часовом поясе задачи
+	for _, zone := range []string{"Pacific/Kiritimati", "Pacific/Pago_Pago"} {
+		location, err := time.LoadLocation(zone)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		id := addTaskFields(t, map[string]any{
+			"title":    "Созвон",
+			"time":     "09:30",
+			"timezone": zone,
+		})
+		task := getTaskFields(t, id)
+		today := serverNow(t).In(location)
+		assert.Equal(t, today.Format(`20060102`), task["date"], zone)
+		assert.Equal(t, "09:30", task["time"])
+		assert.Equal(t, zone, task["timezone"])
+		due := time.Date(today.Year(), today.Month(), today.Day(), 9, 30, 0, 0, location)
+		assert.Equal(t, due.Format(time.RFC3339), task["due"])
+
+		ret, err := postJSON("api/task/done?id="+id, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.Empty(t, ret)
+		completedAt, err := time.Parse(time.RFC3339, getTaskFields(t, id)["completed_at"])
+		assert.NoError(t, err)
+		_, offset := completedAt.Zone()
+		_, want := today.Zone()
+		assert.Equal(t, want, offset, zone)
+	}
+}
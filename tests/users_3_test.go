@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go_final_project/internal/models"
+)
+
+// signup регистрирует пользователя login с паролем password и возвращает код состояния и выданные токены.
+func signup(t *testing.T, login, password string) (int, tokenPair) {
+	status, body := authRequest(t, http.MethodPost, "api/signup", map[string]any{"login": login, "password": password}, nil)
+	var tokens tokenPair
+	require.NoError(t, json.Unmarshal(body, &tokens), string(body))
+	return status, tokens
+}
+
+// uniqueLogin возвращает логин, которого ещё нет в базе данных сервера.
+func uniqueLogin(prefix string) string {
+	return fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+}
+
+func TestSignUp(t *testing.T) {
+	authPassword(t)
+	login := uniqueLogin("alice")
+
+	status, tokens := signup(t, login, "alice-password")
+	require.Equal(t, http.StatusOK, status)
+	assert.NotEmpty(t, tokens.Token)
+	status, _ = authRequest(t, http.MethodGet, "api/tasks", nil, bearer(tokens.Token))
+	assert.Equal(t, http.StatusOK, status)
+
+	for _, v := range []struct {
+		login, password string
+		status          int
+	}{
+		{login, "other-password", http.StatusConflict},
+		{"", "alice-password", http.StatusBadRequest},
+		{uniqueLogin("short"), "1234567", http.StatusBadRequest},
+	} {
+		status, _ = signup(t, v.login, v.password)
+		assert.Equal(t, v.status, status, v)
+	}
+
+	signin(t, login, "alice-password")
+	status, _ = authRequest(t, http.MethodPost, "api/signin", map[string]any{"login": login, "password": "wrong-password"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestTaskOwnership(t *testing.T) {
+	password := authPassword(t)
+	_, alice := signup(t, uniqueLogin("alice"), "alice-password")
+	_, bob := signup(t, uniqueLogin("bob"), "bob-password")
+	owner := bearer(alice.Token)
+
+	status, body := authRequest(t, http.MethodPost, "api/task", map[string]any{"title": "Задача Алисы"}, owner)
+	require.Equal(t, http.StatusOK, status, string(body))
+	var created struct {
+		ID json.Number `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(body, &created))
+	id := created.ID.String()
+	defer authRequest(t, http.MethodDelete, "api/task?id="+id, nil, owner)
+
+	status, _ = authRequest(t, http.MethodGet, "api/task?id="+id, nil, owner)
+	assert.Equal(t, http.StatusOK, status)
+
+	// ни другой пользователь, ни пользователь общего пароля не видят и не изменяют чужую задачу
+	shared := signin(t, "", password)
+	for _, headers := range []map[string]string{bearer(bob.Token), bearer(shared.Token)} {
+		for _, v := range []struct {
+			method string
+			path   string
+			values map[string]any
+		}{
+			{http.MethodGet, "api/task?id=" + id, nil},
+			{http.MethodPut, "api/task", map[string]any{"id": id, "title": "Чужая задача"}},
+			{http.MethodPost, "api/task/done?id=" + id, nil},
+			{http.MethodDelete, "api/task?id=" + id, nil},
+		} {
+			status, body := authRequest(t, v.method, v.path, v.values, headers)
+			assert.Equal(t, http.StatusNotFound, status, "%s %s: %s", v.method, v.path, body)
+		}
+		status, body := authRequest(t, http.MethodGet, "api/tasks?limit=500", nil, headers)
+		require.Equal(t, http.StatusOK, status)
+		var page taskPage
+		require.NoError(t, json.Unmarshal(body, &page))
+		for _, task := range page.Tasks {
+			assert.NotEqual(t, id, task["id"])
+		}
+	}
+
+	status, body = authRequest(t, http.MethodGet, "api/task?id="+id, nil, owner)
+	require.Equal(t, http.StatusOK, status)
+	var task map[string]string
+	require.NoError(t, json.Unmarshal(body, &task))
+	assert.Equal(t, "Задача Алисы", task["title"])
+}
+
+// TestCreateUserConcurrent регистрирует один логин одновременно несколькими запросами:
+// пользователь должен создаться один раз, остальные запросы получают ErrUserExists.
+func TestCreateUserConcurrent(t *testing.T) {
+	for name, dsn := range storeDSNs(t) {
+		t.Run(name, func(t *testing.T) {
+			store := openStore(t, dsn, time.Now())
+			login := uniqueLogin("race")
+
+			const requests = 8
+			var (
+				wg      sync.WaitGroup
+				mu      sync.Mutex
+				created int
+			)
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := store.CreateUser(login, "race-password")
+					if err != nil {
+						assert.ErrorIs(t, err, models.ErrUserExists)
+						return
+					}
+					mu.Lock()
+					created++
+					mu.Unlock()
+				}()
+			}
+			wg.Wait()
+			assert.Equal(t, 1, created)
+
+			user, err := store.Authenticate(login, "race-password")
+			require.NoError(t, err)
+			assert.Equal(t, login, user.Login)
+		})
+	}
+}